@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"steamSkinIDs/pkg/marketids"
+)
+
+func saveMarketIDsBinary(output *outputSet, m *marketids.MarketIDs, filePath string) error {
+	for _, ids := range []map[string]int{m.Steam, m.Buff163, m.BuffMarket, m.C5Game, m.Youpin898, m.IGXE} {
+		if ids == nil {
+			return nil
+		}
+	}
+
+	return writeFile(output, filePath, func(w io.Writer) error {
+		if _, err := w.Write(marketids.Encode(m)); err != nil {
+			return fmt.Errorf("Failed to write binary market ids to %s: %w", filePath, err)
+		}
+		return nil
+	})
+}
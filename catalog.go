@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// Catalog holds every dataset produced by a run, keyed the same way as the
+// files written to mini/ and pretty/.
+type Catalog struct {
+	DefIndexes   map[string]int
+	PaintIndexes map[string]int
+	Phases       map[string]SkinPhases
+
+	SteamAgentIDs       map[string]int
+	SteamCollectibleIDs map[string]int
+	SteamCrateIDs       map[string]int
+	SteamGraffitiIDs    map[string]string
+	SteamGraffiti       map[string]GraffitiID
+	GraffitiTints       map[string]int
+	SteamHighlightIDs   map[string]string
+	SteamKeychainIDs    map[string]int
+	SteamKeyIDs         map[string]any
+	SteamKeys           map[string]KeyRecord
+	CrateKeys           map[string]CrateKeys
+	SteamMusicKitIDs    map[string]int
+	SteamPatchIDs       map[string]int
+	SteamStickerIDs     map[string]int
+	StickerMetadata     map[string]StickerMetadata
+
+	SteamMarketIDs map[string]int
+	Buff163IDs     map[string]int
+	BuffMarketIDs  map[string]int
+	C5GameIDs      map[string]int
+	YoupinIDs      map[string]int
+	IGXEIDs        map[string]int
+
+	Buff163StickerIDs        map[string]int
+	Buff163PaintseedGroupIDs map[string]map[string]int
+	Buff163PhaseIDs          map[string]map[string]int
+	Buff163TagIDs            map[string]map[string]int
+	Buff163PatchIDs          map[string]int
+	Buff163Patterns          map[string]map[string][]int
+	PatternTiers             map[string]SeedTiers
+}
+
+// catalogFile ties a dataset to the path it is saved under, relative to
+// mini/ or pretty/.
+type catalogFile struct {
+	path   string
+	target any
+}
+
+func (c *Catalog) files() []catalogFile {
+	return []catalogFile{
+		{"steam_indexes/def_indexes.json", &c.DefIndexes},
+		{"steam_indexes/paint_indexes.json", &c.PaintIndexes},
+		{"steam_indexes/phases.json", &c.Phases},
+
+		{"steam_grouped_ids/agents.json", &c.SteamAgentIDs},
+		{"steam_grouped_ids/collectibles.json", &c.SteamCollectibleIDs},
+		{"steam_grouped_ids/crates.json", &c.SteamCrateIDs},
+		{"steam_grouped_ids/graffiti.json", &c.SteamGraffitiIDs},
+		{"steam_grouped_ids/graffiti_kits.json", &c.SteamGraffiti},
+		{"steam_grouped_ids/graffiti_tints.json", &c.GraffitiTints},
+		{"steam_grouped_ids/highlights.json", &c.SteamHighlightIDs},
+		{"steam_grouped_ids/keychains.json", &c.SteamKeychainIDs},
+		{"steam_grouped_ids/keys.json", &c.SteamKeyIDs},
+		{"steam_grouped_ids/keys_v2.json", &c.SteamKeys},
+		{"steam_grouped_ids/crate_keys.json", &c.CrateKeys},
+		{"steam_grouped_ids/music_kits.json", &c.SteamMusicKitIDs},
+		{"steam_grouped_ids/patches.json", &c.SteamPatchIDs},
+		{"steam_grouped_ids/stickers.json", &c.SteamStickerIDs},
+		{"steam_grouped_ids/sticker_metadata.json", &c.StickerMetadata},
+
+		{"market_ids/steam.json", &c.SteamMarketIDs},
+		{"market_ids/buff163.json", &c.Buff163IDs},
+		{"market_ids/buff_market.json", &c.BuffMarketIDs},
+		{"market_ids/c5game.json", &c.C5GameIDs},
+		{"market_ids/youpin898.json", &c.YoupinIDs},
+		{"market_ids/igxe.json", &c.IGXEIDs},
+
+		{"buff163_grouped_ids/stickers.json", &c.Buff163StickerIDs},
+		{"buff163_grouped_ids/paintseed_group_ids.json", &c.Buff163PaintseedGroupIDs},
+		{"buff163_grouped_ids/phases.json", &c.Buff163PhaseIDs},
+		{"buff163_grouped_ids/tags.json", &c.Buff163TagIDs},
+		{"buff163_grouped_ids/patches.json", &c.Buff163PatchIDs},
+		{"buff163_grouped_ids/patterns.json", &c.Buff163Patterns},
+		{"buff163_grouped_ids/pattern_tiers.json", &c.PatternTiers},
+	}
+}
+
+// loadCatalog reads a catalog back from the JSON files in dir, usually
+// ./mini. Files that do not exist leave their dataset nil.
+func loadCatalog(dir string) (*Catalog, error) {
+	catalog := &Catalog{}
+
+	for _, file := range catalog.files() {
+		err := loadData(filepath.Join(dir, file.path), file.target)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	// Derived datasets can be rebuilt when the files predate them.
+	if catalog.Phases == nil {
+		catalog.Phases = buildPhases(catalog.PaintIndexes, catalog.Buff163PhaseIDs)
+	}
+	if catalog.SteamGraffiti == nil {
+		catalog.SteamGraffiti = buildGraffiti(catalog.SteamGraffitiIDs)
+	}
+	if catalog.GraffitiTints == nil {
+		catalog.GraffitiTints = graffitiTints
+	}
+	if catalog.CrateKeys == nil {
+		catalog.CrateKeys = buildCrateKeys(catalog)
+	}
+	if catalog.PatternTiers == nil {
+		catalog.PatternTiers = buildPatternTiers(catalog.Buff163Patterns)
+	}
+
+	return catalog, nil
+}
+
+func loadData(filePath string, target any) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("Failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("Failed to decode file %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// missing returns the names of datasets that were not fetched.
+func (c *Catalog) missing() []string {
+	value := reflect.ValueOf(c).Elem()
+
+	var names []string
+	for i := range value.NumField() {
+		if value.Field(i).IsNil() {
+			names = append(names, value.Type().Field(i).Name)
+		}
+	}
+
+	return names
+}
+
+// idSource is one dictionary of IDs, named by the marketplace the IDs belong to
+// and their kind: "market" for market_ids, the dictionary name otherwise.
+type idSource struct {
+	marketplace string
+	kind        string
+	ids         map[string]any
+}
+
+func toAnyMap[T any](data map[string]T) map[string]any {
+	m := make(map[string]any, len(data))
+	for name, value := range data {
+		m[name] = value
+	}
+	return m
+}
+
+func (c *Catalog) idSources() []idSource {
+	return []idSource{
+		{"steam", "market", toAnyMap(c.SteamMarketIDs)},
+		{"buff163", "market", toAnyMap(c.Buff163IDs)},
+		{"buff_market", "market", toAnyMap(c.BuffMarketIDs)},
+		{"c5game", "market", toAnyMap(c.C5GameIDs)},
+		{"youpin898", "market", toAnyMap(c.YoupinIDs)},
+		{"igxe", "market", toAnyMap(c.IGXEIDs)},
+
+		{"steam", "agents", toAnyMap(c.SteamAgentIDs)},
+		{"steam", "collectibles", toAnyMap(c.SteamCollectibleIDs)},
+		{"steam", "crates", toAnyMap(c.SteamCrateIDs)},
+		{"steam", "graffiti", toAnyMap(c.SteamGraffitiIDs)},
+		{"steam", "highlights", toAnyMap(c.SteamHighlightIDs)},
+		{"steam", "keychains", toAnyMap(c.SteamKeychainIDs)},
+		{"steam", "keys", toAnyMap(c.SteamKeyIDs)},
+		{"steam", "music_kits", toAnyMap(c.SteamMusicKitIDs)},
+		{"steam", "patches", toAnyMap(c.SteamPatchIDs)},
+		{"steam", "stickers", toAnyMap(c.SteamStickerIDs)},
+
+		{"buff163", "stickers", toAnyMap(c.Buff163StickerIDs)},
+		{"buff163", "patches", toAnyMap(c.Buff163PatchIDs)},
+	}
+}
+
+func (c *Catalog) itemNames() []string {
+	seen := make(map[string]struct{})
+	for _, source := range c.idSources() {
+		for name := range source.ids {
+			seen[name] = struct{}{}
+		}
+	}
+	for _, nested := range []map[string]map[string]int{c.Buff163PhaseIDs, c.Buff163TagIDs, c.Buff163PaintseedGroupIDs} {
+		for name := range nested {
+			seen[name] = struct{}{}
+		}
+	}
+	for name := range c.Buff163Patterns {
+		seen[name] = struct{}{}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
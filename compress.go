@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressors produce the precompressed copies of every mini/ file, named
+// after the Content-Encoding a CDN serves them with.
+var compressors = []struct {
+	extension string
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+}{
+	{".gz", func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	}},
+	{".br", func(w io.Writer) (io.WriteCloser, error) {
+		return brotli.NewWriterLevel(w, brotli.BestCompression), nil
+	}},
+	{".zst", func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	}},
+}
+
+func saveCompressed[T any](output *outputSet, data map[string]T, filePath string, newWriter func(w io.Writer) (io.WriteCloser, error)) error {
+	if data == nil {
+		return nil
+	}
+
+	return writeFile(output, filePath, func(w io.Writer) error {
+		compressor, err := newWriter(w)
+		if err != nil {
+			return fmt.Errorf("Failed to create compressor for %s: %w", filePath, err)
+		}
+
+		if err := encodeData(compressor, data, false); err != nil {
+			compressor.Close()
+			return fmt.Errorf("Failed to encode data to JSON for %s: %w", filePath, err)
+		}
+
+		if err := compressor.Close(); err != nil {
+			return fmt.Errorf("Failed to compress data for %s: %w", filePath, err)
+		}
+
+		return nil
+	})
+}
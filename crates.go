@@ -0,0 +1,87 @@
+package main
+
+import "strconv"
+
+// CrateKeys links a crate to the keys that open it, with the marketplace IDs
+// of both keyed like market_ids. Keys is empty for keyless crates such as
+// capsules and souvenir packages.
+type CrateKeys struct {
+	CrateIDs map[string]int            `json:"crate_ids"`
+	Keys     map[string]map[string]int `json:"keys"`
+}
+
+func (CrateKeys) columns() []string {
+	return []string{"key", "marketplace", "crate_id", "key_id"}
+}
+
+// rows has one row per key and marketplace, keyless crates get a single
+// row per marketplace with an empty key.
+func (entry CrateKeys) rows() [][]string {
+	keys := sortedKeys(entry.Keys)
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+
+	var rows [][]string
+	for _, key := range keys {
+		for _, marketplace := range sortedKeys(entry.CrateIDs) {
+			keyID := ""
+			if id, exists := entry.Keys[key][marketplace]; exists {
+				keyID = strconv.Itoa(id)
+			}
+			rows = append(rows, []string{key, marketplace, strconv.Itoa(entry.CrateIDs[marketplace]), keyID})
+		}
+	}
+	return rows
+}
+
+// marketIDs returns the IDs of an item on every marketplace, keyed like
+// market_ids.
+func (c *Catalog) marketIDs(name string) map[string]int {
+	markets := map[string]map[string]int{
+		"steam":       c.SteamMarketIDs,
+		"buff163":     c.Buff163IDs,
+		"buff_market": c.BuffMarketIDs,
+		"c5game":      c.C5GameIDs,
+		"youpin898":   c.YoupinIDs,
+		"igxe":        c.IGXEIDs,
+	}
+
+	ids := make(map[string]int)
+	for marketplace, marketIDs := range markets {
+		if id, exists := marketIDs[name]; exists {
+			ids[marketplace] = id
+		}
+	}
+
+	return ids
+}
+
+// buildCrateKeys joins every crate of steam_grouped_ids/crates.json with the
+// keys that list it.
+func buildCrateKeys(c *Catalog) map[string]CrateKeys {
+	if c.SteamCrateIDs == nil || c.SteamKeys == nil {
+		return nil
+	}
+
+	keysByCrate := make(map[string][]string)
+	for _, key := range sortedKeys(c.SteamKeys) {
+		for _, crate := range c.SteamKeys[key].Crates {
+			keysByCrate[crate] = append(keysByCrate[crate], key)
+		}
+	}
+
+	crateKeys := make(map[string]CrateKeys, len(c.SteamCrateIDs))
+	for crate := range c.SteamCrateIDs {
+		entry := CrateKeys{
+			CrateIDs: c.marketIDs(crate),
+			Keys:     make(map[string]map[string]int),
+		}
+		for _, key := range keysByCrate[crate] {
+			entry.Keys[key] = c.marketIDs(key)
+		}
+		crateKeys[crate] = entry
+	}
+
+	return crateKeys
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildCrateKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog *Catalog
+		want    map[string]CrateKeys
+	}{
+		{
+			name: "keyed and keyless crates",
+			catalog: &Catalog{
+				SteamMarketIDs: map[string]int{"Revolution Case": 1, "Revolution Case Key": 2, "Paris 2023 Legends Sticker Capsule": 3},
+				Buff163IDs:     map[string]int{"Revolution Case": 10, "Revolution Case Key": 20},
+				SteamCrateIDs:  map[string]int{"Revolution Case": 4904, "Paris 2023 Legends Sticker Capsule": 4890},
+				SteamKeys: map[string]KeyRecord{
+					"Revolution Case Key": {Crates: []string{"Revolution Case"}},
+				},
+			},
+			want: map[string]CrateKeys{
+				"Revolution Case": {
+					CrateIDs: map[string]int{"steam": 1, "buff163": 10},
+					Keys:     map[string]map[string]int{"Revolution Case Key": {"steam": 2, "buff163": 20}},
+				},
+				"Paris 2023 Legends Sticker Capsule": {
+					CrateIDs: map[string]int{"steam": 3},
+					Keys:     map[string]map[string]int{},
+				},
+			},
+		},
+		{
+			name: "crate opened by several keys",
+			catalog: &Catalog{
+				SteamCrateIDs: map[string]int{"Operation Case": 1},
+				SteamKeys: map[string]KeyRecord{
+					"Operation Key":        {Crates: []string{"Operation Case"}},
+					"Operation Key (Gift)": {Crates: []string{"Operation Case"}},
+				},
+			},
+			want: map[string]CrateKeys{
+				"Operation Case": {
+					CrateIDs: map[string]int{},
+					Keys:     map[string]map[string]int{"Operation Key": {}, "Operation Key (Gift)": {}},
+				},
+			},
+		},
+		{
+			name: "key crates missing from crates are ignored",
+			catalog: &Catalog{
+				SteamCrateIDs: map[string]int{"Revolution Case": 4904},
+				SteamKeys: map[string]KeyRecord{
+					"Revolution Case Key": {Crates: []string{"Revolution Case"}},
+					"Old Case Key":        {Crates: []string{"Retired Case"}},
+				},
+			},
+			want: map[string]CrateKeys{
+				"Revolution Case": {
+					CrateIDs: map[string]int{},
+					Keys:     map[string]map[string]int{"Revolution Case Key": {}},
+				},
+			},
+		},
+		{name: "missing crates", catalog: &Catalog{SteamKeys: map[string]KeyRecord{}}, want: nil},
+		{name: "missing keys", catalog: &Catalog{SteamCrateIDs: map[string]int{}}, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := buildCrateKeys(test.catalog); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("buildCrateKeys() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// tableRows is implemented by dataset values that flatten to more columns
+// than a name and an ID. columns names the columns after the leading name
+// column and rows returns the rows of one item.
+type tableRows interface {
+	columns() []string
+	rows() [][]string
+}
+
+// flattenRows turns a dataset into a header and rows sorted by name. Values
+// implementing tableRows provide their own columns, nested maps are expanded
+// to one row per sub key (name, sub_key, id), patterns to one row per paint
+// seed (name, tier, seed) and everything else to (name, id).
+func flattenRows[T any](data map[string]T) ([]string, [][]string) {
+	names := sortedKeys(data)
+
+	var zero T
+	if table, isTable := any(zero).(tableRows); isTable {
+		rows := make([][]string, 0, len(data))
+		for _, name := range names {
+			for _, row := range any(data[name]).(tableRows).rows() {
+				rows = append(rows, append([]string{name}, row...))
+			}
+		}
+		return append([]string{"name"}, table.columns()...), rows
+	}
+
+	switch nested := any(data).(type) {
+
+	case map[string]map[string]int:
+		rows := make([][]string, 0, len(nested))
+		for _, name := range names {
+			subKeys := sortedKeys(nested[name])
+			for _, subKey := range subKeys {
+				rows = append(rows, []string{name, subKey, strconv.Itoa(nested[name][subKey])})
+			}
+		}
+		return []string{"name", "sub_key", "id"}, rows
+
+	case map[string]map[string][]int:
+		rows := make([][]string, 0, len(nested))
+		for _, name := range names {
+			tiers := sortedKeys(nested[name])
+			for _, tier := range tiers {
+				for _, seed := range nested[name][tier] {
+					rows = append(rows, []string{name, tier, strconv.Itoa(seed)})
+				}
+			}
+		}
+		return []string{"name", "tier", "seed"}, rows
+
+	default:
+		rows := make([][]string, 0, len(data))
+		for _, name := range names {
+			rows = append(rows, []string{name, fmt.Sprint(data[name])})
+		}
+		return []string{"name", "id"}, rows
+	}
+}
+
+func sortedKeys[T any](data map[string]T) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func saveCSV[T any](output *outputSet, data map[string]T, filePath string) error {
+	if data == nil {
+		return nil
+	}
+
+	header, rows := flattenRows(data)
+
+	return writeFile(output, filePath, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("Failed to encode data to CSV for %s: %w", filePath, err)
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return fmt.Errorf("Failed to encode data to CSV for %s: %w", filePath, err)
+		}
+		return nil
+	})
+}
+
+func saveTSV[T any](output *outputSet, data map[string]T, filePath string) error {
+	if data == nil {
+		return nil
+	}
+
+	header, rows := flattenRows(data)
+
+	return writeFile(output, filePath, func(w io.Writer) error {
+		var builder strings.Builder
+		for _, row := range append([][]string{header}, rows...) {
+			builder.Reset()
+			for i, field := range row {
+				if i > 0 {
+					builder.WriteByte('\t')
+				}
+				builder.WriteString(tsvEscaper.Replace(field))
+			}
+			builder.WriteByte('\n')
+
+			if _, err := io.WriteString(w, builder.String()); err != nil {
+				return fmt.Errorf("Failed to encode data to TSV for %s: %w", filePath, err)
+			}
+		}
+		return nil
+	})
+}
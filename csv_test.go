@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenRows(t *testing.T) {
+	defIndex, tintID := 1203, 7
+
+	tests := []struct {
+		name   string
+		flat   func() ([]string, [][]string)
+		header []string
+		rows   [][]string
+	}{
+		{
+			name:   "plain IDs",
+			flat:   func() ([]string, [][]string) { return flattenRows(map[string]int{"b": 2, "a": 1}) },
+			header: []string{"name", "id"},
+			rows:   [][]string{{"a", "1"}, {"b", "2"}},
+		},
+		{
+			name: "nested IDs",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]map[string]int{"a": {"y": 2, "x": 1}})
+			},
+			header: []string{"name", "sub_key", "id"},
+			rows:   [][]string{{"a", "x", "1"}, {"a", "y", "2"}},
+		},
+		{
+			name: "patterns",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]map[string][]int{"a": {"tier 2": {5}, "tier 1": {9, 3}}})
+			},
+			header: []string{"name", "tier", "seed"},
+			rows:   [][]string{{"a", "tier 1", "9"}, {"a", "tier 1", "3"}, {"a", "tier 2", "5"}},
+		},
+		{
+			name: "keys",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]KeyRecord{
+					"Key": {ID: "key_1", DefIndex: &defIndex, Marketable: true, Crates: []string{"A", "B"}},
+				})
+			},
+			header: []string{"name", "id", "def_index", "marketable", "crates"},
+			rows:   [][]string{{"Key", "key_1", "1203", "true", "A;B"}},
+		},
+		{
+			name: "graffiti without tint",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]GraffitiID{
+					"Tinted": {StickerKitID: 10, TintID: &tintID, Tint: "Red"},
+					"Plain":  {StickerKitID: 11},
+				})
+			},
+			header: []string{"name", "sticker_kit_id", "tint_id", "tint"},
+			rows:   [][]string{{"Plain", "11", "", ""}, {"Tinted", "10", "7", "Red"}},
+		},
+		{
+			name: "keyless crate",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]CrateKeys{
+					"Capsule": {CrateIDs: map[string]int{"steam": 2, "buff163": 1}},
+				})
+			},
+			header: []string{"name", "key", "marketplace", "crate_id", "key_id"},
+			rows:   [][]string{{"Capsule", "", "buff163", "1", ""}, {"Capsule", "", "steam", "2", ""}},
+		},
+		{
+			name: "crate with key missing on a marketplace",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]CrateKeys{
+					"Case": {
+						CrateIDs: map[string]int{"steam": 2, "buff163": 1},
+						Keys:     map[string]map[string]int{"Case Key": {"steam": 4}},
+					},
+				})
+			},
+			header: []string{"name", "key", "marketplace", "crate_id", "key_id"},
+			rows:   [][]string{{"Case", "Case Key", "buff163", "1", ""}, {"Case", "Case Key", "steam", "2", "4"}},
+		},
+		{
+			name: "phases with and without listings",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]SkinPhases{
+					"Doppler": {
+						"Ruby":    {PaintIndex: 415, Buff163IDs: map[string]int{"Doppler (FN)": 2, "Doppler (MW)": 3}},
+						"Phase 1": {PaintIndex: 418},
+					},
+				})
+			},
+			header: []string{"name", "phase", "paint_index", "market_hash_name", "buff163_id"},
+			rows: [][]string{
+				{"Doppler", "Phase 1", "418", "", ""},
+				{"Doppler", "Ruby", "415", "Doppler (FN)", "2"},
+				{"Doppler", "Ruby", "415", "Doppler (MW)", "3"},
+			},
+		},
+		{
+			name: "seed tiers sorted numerically",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]SeedTiers{"Case Hardened": {100: "tier 2", 9: "tier 1"}})
+			},
+			header: []string{"name", "seed", "tier"},
+			rows:   [][]string{{"Case Hardened", "9", "tier 1"}, {"Case Hardened", "100", "tier 2"}},
+		},
+		{
+			name: "stickers",
+			flat: func() ([]string, [][]string) {
+				return flattenRows(map[string]StickerMetadata{
+					"Sticker": {StickerKitID: 5, Type: "Event", Effect: "Holo", TournamentEvent: "Major", Crates: []string{"A", "B"}},
+				})
+			},
+			header: []string{"name", "sticker_kit_id", "type", "effect", "tournament_event", "tournament_team", "tournament_player", "crates"},
+			rows:   [][]string{{"Sticker", "5", "Event", "Holo", "Major", "", "", "A;B"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header, rows := test.flat()
+			if !reflect.DeepEqual(header, test.header) {
+				t.Errorf("header = %q, want %q", header, test.header)
+			}
+			if !reflect.DeepEqual(rows, test.rows) {
+				t.Errorf("rows = %q, want %q", rows, test.rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const exclusionsVersion = 1
+
+// ExclusionRule removes items from datasets, either by exact name or by a
+// regular expression matched against the name. Datasets are file paths
+// relative to mini/, a rule without datasets applies to all of them.
+type ExclusionRule struct {
+	Name     string   `json:"name"`
+	Reason   string   `json:"reason"`
+	Datasets []string `json:"datasets,omitempty"`
+	Names    []string `json:"names,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+
+	names   map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+type ExclusionConfig struct {
+	Version int             `json:"version"`
+	Rules   []ExclusionRule `json:"rules"`
+}
+
+func loadExclusions(filePath string) (*ExclusionConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read exclusions %s: %w", filePath, err)
+	}
+
+	var config ExclusionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Failed to decode exclusions %s: %w", filePath, err)
+	}
+
+	if config.Version != exclusionsVersion {
+		return nil, fmt.Errorf("Unsupported exclusions version %d in %s, expected %d", config.Version, filePath, exclusionsVersion)
+	}
+
+	datasets := make(map[string]bool)
+	for _, file := range (&Catalog{}).files() {
+		datasets[file.path] = true
+	}
+
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+
+		if rule.Name == "" {
+			return nil, fmt.Errorf("Exclusion rule %d in %s has no name", i, filePath)
+		}
+
+		// Exclusions are applied before the derived datasets are built, so a
+		// derived dataset loses the items excluded from its sources instead.
+		for _, dataset := range rule.Datasets {
+			if !datasets[dataset] {
+				return nil, fmt.Errorf("Exclusion rule %s targets unknown dataset %s", rule.Name, dataset)
+			}
+			if derivedDatasets[dataset] {
+				return nil, fmt.Errorf("Exclusion rule %s targets %s, which is derived from other datasets", rule.Name, dataset)
+			}
+		}
+
+		rule.names = make(map[string]struct{}, len(rule.Names))
+		for _, name := range rule.Names {
+			rule.names[name] = struct{}{}
+		}
+
+		if rule.Pattern != "" {
+			rule.pattern, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid pattern in exclusion rule %s: %w", rule.Name, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+func (rule *ExclusionRule) appliesTo(dataset string) bool {
+	if len(rule.Datasets) == 0 {
+		return true
+	}
+	for _, candidate := range rule.Datasets {
+		if candidate == dataset {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *ExclusionRule) matches(name string) bool {
+	if _, exists := rule.names[name]; exists {
+		return true
+	}
+	return rule.pattern != nil && rule.pattern.MatchString(name)
+}
+
+// apply removes every excluded item from the datasets of the catalog and
+// returns rule name → dataset → excluded names.
+func (config *ExclusionConfig) apply(catalog *Catalog) map[string]map[string][]string {
+	report := make(map[string]map[string][]string, len(config.Rules))
+	for _, rule := range config.Rules {
+		report[rule.Name] = make(map[string][]string)
+	}
+
+	for _, file := range catalog.files() {
+		data := reflect.ValueOf(file.target).Elem()
+		if data.IsNil() {
+			continue
+		}
+
+		for _, key := range data.MapKeys() {
+			name := key.String()
+			for i := range config.Rules {
+				rule := &config.Rules[i]
+				if rule.appliesTo(file.path) && rule.matches(name) {
+					data.SetMapIndex(key, reflect.Value{})
+					report[rule.Name][file.path] = append(report[rule.Name][file.path], name)
+					break
+				}
+			}
+		}
+	}
+
+	for _, datasets := range report {
+		for _, names := range datasets {
+			sort.Strings(names)
+		}
+	}
+
+	return report
+}
+
+func printExclusionReport(report map[string]map[string][]string) {
+	for _, rule := range sortedKeys(report) {
+		if len(report[rule]) == 0 {
+			fmt.Printf("Exclusion rule %s excluded nothing\n", rule)
+			continue
+		}
+		for _, dataset := range sortedKeys(report[rule]) {
+			names := report[rule][dataset]
+			fmt.Printf("Exclusion rule %s excluded %d from %s: %s\n", rule, len(names), dataset, strings.Join(names, ", "))
+		}
+	}
+}
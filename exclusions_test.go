@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadExclusions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "valid", config: `{"version": 1, "rules": [{"name": "a", "names": ["x"]}, {"name": "b", "pattern": "^y"}]}`},
+		{name: "unsupported version", config: `{"version": 2, "rules": []}`, wantErr: "Unsupported exclusions version"},
+		{name: "rule without name", config: `{"version": 1, "rules": [{"names": ["x"]}]}`, wantErr: "has no name"},
+		{name: "invalid pattern", config: `{"version": 1, "rules": [{"name": "a", "pattern": "("}]}`, wantErr: "Invalid pattern"},
+		{name: "invalid JSON", config: `{`, wantErr: "Failed to decode"},
+		{name: "known dataset", config: `{"version": 1, "rules": [{"name": "a", "datasets": ["steam_grouped_ids/crates.json"], "names": ["x"]}]}`},
+		{name: "unknown dataset", config: `{"version": 1, "rules": [{"name": "a", "datasets": ["steam_grouped_ids/module.json"], "names": ["x"]}]}`, wantErr: "targets unknown dataset steam_grouped_ids/module.json"},
+		{name: "derived dataset", config: `{"version": 1, "rules": [{"name": "a", "datasets": ["steam_grouped_ids/crate_keys.json"], "names": ["x"]}]}`, wantErr: "is derived from other datasets"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "exclusions.json")
+			if err := os.WriteFile(filePath, []byte(test.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadExclusions(filePath)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("loadExclusions() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+
+	if _, err := loadExclusions("exclusions.json"); err != nil {
+		t.Errorf("The committed exclusions.json does not load: %v", err)
+	}
+}
+
+func TestApplyExclusions(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "exclusions.json")
+	config := `{"version": 1, "rules": [
+		{"name": "storage", "reason": "r", "datasets": ["steam_grouped_ids/crates.json"], "pattern": "Storage Unit$"},
+		{"name": "exact", "reason": "r", "names": ["Unwanted"]},
+		{"name": "unused", "reason": "r", "names": ["Nothing"]}
+	]}`
+	if err := os.WriteFile(filePath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exclusions, err := loadExclusions(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := &Catalog{
+		SteamCrateIDs:  map[string]int{"Storage Unit": 1, "Operation Breakout Weapon Case": 2, "Unwanted": 3},
+		SteamMarketIDs: map[string]int{"Storage Unit": 1, "Unwanted": 3},
+	}
+	report := exclusions.apply(catalog)
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"crates", catalog.SteamCrateIDs, map[string]int{"Operation Breakout Weapon Case": 2}},
+		{"rule limited to crates keeps other datasets", catalog.SteamMarketIDs, map[string]int{"Storage Unit": 1}},
+		{"nil datasets stay nil", catalog.SteamStickerIDs, map[string]int(nil)},
+		{"report", report, map[string]map[string][]string{
+			"storage": {"steam_grouped_ids/crates.json": {"Storage Unit"}},
+			"exact":   {"steam_grouped_ids/crates.json": {"Unwanted"}, "market_ids/steam.json": {"Unwanted"}},
+			"unused":  {},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.got, test.want) {
+				t.Errorf("got %v, want %v", test.got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+)
+
+// FetchErrorKind tells apart why fetching a source failed.
+type FetchErrorKind int
+
+const (
+	// FetchNetwork means the request could not be sent or the body could
+	// not be read, including cancellation.
+	FetchNetwork FetchErrorKind = iota
+	// FetchStatus means the source answered with a status other than 200.
+	FetchStatus
+	// FetchDecode means the body is not the JSON the fetcher expects.
+	FetchDecode
+	// FetchValidation means the response decoded but failed a sanity check,
+	// such as its Content-Type, its size or its number of items.
+	FetchValidation
+)
+
+func (k FetchErrorKind) String() string {
+	switch k {
+	case FetchNetwork:
+		return "network"
+	case FetchStatus:
+		return "status"
+	case FetchDecode:
+		return "decode"
+	case FetchValidation:
+		return "validation"
+	}
+	return fmt.Sprintf("FetchErrorKind(%d)", int(k))
+}
+
+// FetchError is returned by every fetcher, use errors.As to get its Kind.
+type FetchError struct {
+	Kind FetchErrorKind
+	URL  string
+	Err  error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// sourceLimit bounds what a source may return. maxBodySize caps the
+// decompressed body, minItems is the least number of items the fetcher must
+// extract for the response to be trusted.
+type sourceLimit struct {
+	maxBodySize int64
+	minItems    int
+}
+
+var defaultSourceLimit = sourceLimit{maxBodySize: 64 << 20, minItems: 1}
+
+// sourceLimits are set to several times the current size and about half the
+// current number of items of every source.
+var sourceLimits = map[string]sourceLimit{
+	byMykelAPIBaseURL + "skins.json":        {maxBodySize: 128 << 20, minItems: 1000},
+	byMykelAPIBaseURL + "agents.json":       {maxBodySize: 32 << 20, minItems: 30},
+	byMykelAPIBaseURL + "collectibles.json": {maxBodySize: 32 << 20, minItems: 30},
+	byMykelAPIBaseURL + "crates.json":       {maxBodySize: 32 << 20, minItems: 200},
+	byMykelAPIBaseURL + "graffiti.json":     {maxBodySize: 32 << 20, minItems: 900},
+	byMykelAPIBaseURL + "highlights.json":   {maxBodySize: 32 << 20, minItems: 200},
+	byMykelAPIBaseURL + "keychains.json":    {maxBodySize: 32 << 20, minItems: 30},
+	byMykelAPIBaseURL + "keys.json":         {maxBodySize: 32 << 20, minItems: 10},
+	byMykelAPIBaseURL + "music_kits.json":   {maxBodySize: 32 << 20, minItems: 80},
+	byMykelAPIBaseURL + "patches.json":      {maxBodySize: 32 << 20, minItems: 50},
+	byMykelAPIBaseURL + "stickers.json":     {maxBodySize: 128 << 20, minItems: 4000},
+
+	ericZhuAPIBaseURL + "steam" + counterStrikeJSON: {maxBodySize: 64 << 20, minItems: 10000},
+	ericZhuAPIBaseURL + "buff" + counterStrikeJSON:  {maxBodySize: 64 << 20, minItems: 10000},
+	ericZhuAPIBaseURL + "c5" + counterStrikeJSON:    {maxBodySize: 64 << 20, minItems: 10000},
+	ericZhuAPIBaseURL + "uuyp" + counterStrikeJSON:  {maxBodySize: 64 << 20, minItems: 10000},
+	ericZhuAPIBaseURL + "igxe" + counterStrikeJSON:  {maxBodySize: 64 << 20, minItems: 10000},
+
+	modestSerhatAPIBaseURL + "cs2_marketplaceids.json": {maxBodySize: 128 << 20, minItems: 10000},
+}
+
+func limitFor(url string) sourceLimit {
+	if limit, exists := sourceLimits[url]; exists {
+		return limit
+	}
+	return defaultSourceLimit
+}
+
+func checkMinItems(url string, count int) error {
+	if limit := limitFor(url); count < limit.minItems {
+		return &FetchError{FetchValidation, url, fmt.Errorf("Only %d items from URL %s, expected at least %d", count, url, limit.minItems)}
+	}
+	return nil
+}
+
+// checkContentType rejects responses that cannot be JSON, such as the HTML
+// error pages of a proxy. raw.githubusercontent.com serves JSON as
+// text/plain.
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("Invalid Content-Type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case "application/json", "text/plain", "application/octet-stream":
+		return nil
+	}
+
+	return fmt.Errorf("Unexpected Content-Type %s", mediaType)
+}
+
+var errBodyTooLarge = errors.New("response body too large")
+
+// maxBytesReader fails with errBodyTooLarge once more than remaining bytes
+// have been read, unlike io.LimitReader which silently truncates.
+type maxBytesReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	m.remaining -= int64(n)
+	if m.remaining < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBytesReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "under the limit", body: "12345", limit: 10},
+		{name: "exactly the limit", body: "1234567890", limit: 10},
+		{name: "one byte over", body: "12345678901", limit: 10, wantErr: true},
+		{name: "far over", body: strings.Repeat("x", 1<<16), limit: 10, wantErr: true},
+		{name: "empty", body: "", limit: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := io.ReadAll(&maxBytesReader{r: strings.NewReader(test.body), remaining: test.limit})
+			if test.wantErr {
+				if !errors.Is(err, errBodyTooLarge) {
+					t.Fatalf("ReadAll() error = %v, want errBodyTooLarge", err)
+				}
+				if int64(len(data)) > test.limit+1 {
+					t.Errorf("read %d bytes past a limit of %d", len(data), test.limit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.body {
+				t.Errorf("ReadAll() = %q, want %q", data, test.body)
+			}
+		})
+	}
+}
+
+func TestCheckMinItems(t *testing.T) {
+	skinsURL := byMykelAPIBaseURL + "skins.json"
+
+	tests := []struct {
+		name    string
+		url     string
+		count   int
+		wantErr bool
+	}{
+		{name: "enough items", url: skinsURL, count: 1000},
+		{name: "too few items", url: skinsURL, count: 999, wantErr: true},
+		{name: "default limit", url: "https://example.com/unknown.json", count: 1},
+		{name: "empty source", url: "https://example.com/unknown.json", count: 0, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkMinItems(test.url, test.count)
+			if !test.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) || fetchErr.Kind != FetchValidation {
+				t.Errorf("checkMinItems() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantErr     bool
+	}{
+		{contentType: ""},
+		{contentType: "application/json"},
+		{contentType: "text/plain; charset=utf-8"},
+		{contentType: "application/octet-stream"},
+		{contentType: "text/html; charset=utf-8", wantErr: true},
+		{contentType: "text/plain; charset", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			if err := checkContentType(test.contentType); (err != nil) != test.wantErr {
+				t.Errorf("checkContentType(%q) error = %v, want error %t", test.contentType, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetStreamErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		limit    int64
+		kind     FetchErrorKind
+		wantErr  bool
+		sentinel error
+	}{
+		{
+			name: "valid JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				io.WriteString(w, `{"a": 1}`)
+			},
+		},
+		{
+			name: "gzip encoded JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "gzip")
+				var buffer bytes.Buffer
+				writer := gzip.NewWriter(&buffer)
+				io.WriteString(writer, `{"a": 1}`)
+				writer.Close()
+				w.Write(buffer.Bytes())
+			},
+		},
+		{
+			name: "status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "rate limited", http.StatusTooManyRequests)
+			},
+			wantErr: true,
+			kind:    FetchStatus,
+		},
+		{
+			name: "HTML error page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				io.WriteString(w, "<html></html>")
+			},
+			wantErr: true,
+			kind:    FetchValidation,
+		},
+		{
+			name: "body too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `{"a": "`+strings.Repeat("x", 1024)+`"}`)
+			},
+			limit:    64,
+			wantErr:  true,
+			kind:     FetchValidation,
+			sentinel: errBodyTooLarge,
+		},
+		{
+			name:    "truncated JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, `{"a": `) },
+			wantErr: true,
+			kind:    FetchDecode,
+		},
+		{
+			name: "invalid gzip",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "gzip")
+				io.WriteString(w, "not gzip")
+			},
+			wantErr: true,
+			kind:    FetchDecode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.handler)
+			defer server.Close()
+
+			url := server.URL + "/data.json"
+			if test.limit > 0 {
+				sourceLimits[url] = sourceLimit{maxBodySize: test.limit, minItems: 1}
+				defer delete(sourceLimits, url)
+			}
+
+			var target map[string]any
+			err := getStream(context.Background(), url, func(r io.Reader) error {
+				return json.NewDecoder(r).Decode(&target)
+			})
+			if !test.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) {
+				t.Fatalf("getStream() error = %v, want a FetchError", err)
+			}
+			if fetchErr.Kind != test.kind || fetchErr.URL != url {
+				t.Errorf("getStream() error kind = %s for %s, want %s for %s", fetchErr.Kind, fetchErr.URL, test.kind, url)
+			}
+			if test.sentinel != nil && !errors.Is(err, test.sentinel) {
+				t.Errorf("getStream() error = %v, want it to wrap %v", err, test.sentinel)
+			}
+		})
+	}
+}
+
+func TestGetStreamNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL + "/data.json"
+	server.Close()
+
+	err := getStream(context.Background(), url, func(r io.Reader) error { return nil })
+
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Kind != FetchNetwork {
+		t.Errorf("getStream() error = %v, want a network error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// graffitiTints are the spray tints of items_game.txt, the tint ID is the
+// number after the underscore in graffiti IDs like "3983_7".
+var graffitiTints = map[string]int{
+	"Brick Red":      1,
+	"Blood Red":      2,
+	"Tiger Orange":   3,
+	"Dust Brown":     4,
+	"Desert Amber":   5,
+	"Tracer Yellow":  6,
+	"Battle Green":   7,
+	"Jungle Green":   8,
+	"Frog Green":     9,
+	"Cash Green":     10,
+	"Wire Blue":      11,
+	"Monarch Blue":   12,
+	"SWAT Blue":      13,
+	"Violent Violet": 14,
+	"Monster Purple": 15,
+	"Bazooka Pink":   16,
+	"Princess Pink":  17,
+	"War Pig Pink":   18,
+	"Shark White":    19,
+}
+
+// GraffitiID is a graffiti ID split into the sticker kit and, for tinted
+// graffiti, the spray tint.
+type GraffitiID struct {
+	StickerKitID int    `json:"sticker_kit_id"`
+	TintID       *int   `json:"tint_id,omitempty"`
+	Tint         string `json:"tint,omitempty"`
+}
+
+func (GraffitiID) columns() []string {
+	return []string{"sticker_kit_id", "tint_id", "tint"}
+}
+
+func (graffiti GraffitiID) rows() [][]string {
+	tintID := ""
+	if graffiti.TintID != nil {
+		tintID = strconv.Itoa(*graffiti.TintID)
+	}
+	return [][]string{{strconv.Itoa(graffiti.StickerKitID), tintID, graffiti.Tint}}
+}
+
+func tintName(tintID int) string {
+	for name, id := range graffitiTints {
+		if id == tintID {
+			return name
+		}
+	}
+	return ""
+}
+
+func parseGraffitiID(id string) (GraffitiID, error) {
+	kit, tint, hasTint := strings.Cut(id, "_")
+
+	stickerKitID, err := strconv.Atoi(kit)
+	if err != nil {
+		return GraffitiID{}, fmt.Errorf("Invalid sticker kit in graffiti ID %s", id)
+	}
+
+	graffiti := GraffitiID{StickerKitID: stickerKitID}
+
+	if hasTint {
+		tintID, err := strconv.Atoi(tint)
+		if err != nil {
+			return GraffitiID{}, fmt.Errorf("Invalid tint in graffiti ID %s", id)
+		}
+		graffiti.TintID = &tintID
+		graffiti.Tint = tintName(tintID)
+	}
+
+	return graffiti, nil
+}
+
+// buildGraffiti parses the IDs of steam_grouped_ids/graffiti.json, skipping
+// the ones that cannot be parsed.
+func buildGraffiti(graffitiIDs map[string]string) map[string]GraffitiID {
+	if graffitiIDs == nil {
+		return nil
+	}
+
+	graffiti := make(map[string]GraffitiID, len(graffitiIDs))
+	for name, id := range graffitiIDs {
+		if parsed, err := parseGraffitiID(id); err == nil {
+			graffiti[name] = parsed
+		}
+	}
+
+	return graffiti
+}
+
+// graffitiKey identifies a graffiti by the "sticker slot 0 id" and "spray
+// tint id" attributes of an inventory item. A tint ID of 0 means the graffiti
+// is not tinted.
+type graffitiKey struct {
+	stickerKitID int
+	tintID       int
+}
+
+// graffitiNames indexes graffiti by sticker kit and tint. When two names
+// share both, the first name in sort order wins.
+func graffitiNames(graffiti map[string]GraffitiID) map[graffitiKey]string {
+	names := make(map[graffitiKey]string, len(graffiti))
+
+	for _, name := range sortedKeys(graffiti) {
+		key := graffitiKey{stickerKitID: graffiti[name].StickerKitID}
+		if tintID := graffiti[name].TintID; tintID != nil {
+			key.tintID = *tintID
+		}
+		if _, exists := names[key]; !exists {
+			names[key] = name
+		}
+	}
+
+	return names
+}
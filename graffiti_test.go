@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGraffitiID(t *testing.T) {
+	tintID := 7
+
+	tests := []struct {
+		id      string
+		want    GraffitiID
+		wantErr bool
+	}{
+		{id: "3983", want: GraffitiID{StickerKitID: 3983}},
+		{id: "3983_7", want: GraffitiID{StickerKitID: 3983, TintID: &tintID, Tint: "Battle Green"}},
+		{id: "3983_99", want: GraffitiID{StickerKitID: 3983, TintID: func() *int { id := 99; return &id }()}},
+		{id: "kit_7", wantErr: true},
+		{id: "3983_tint", wantErr: true},
+		{id: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			graffiti, err := parseGraffitiID(test.id)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseGraffitiID(%q) = %+v, want an error", test.id, graffiti)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(graffiti, test.want) {
+				t.Errorf("parseGraffitiID(%q) = %+v, want %+v", test.id, graffiti, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveGraffiti(t *testing.T) {
+	graffiti := buildGraffiti(map[string]string{
+		"Sealed Graffiti | Recoil AK-47":                   "3983",
+		"Sealed Graffiti | Lambda (Battle Green)":          "1711_7",
+		"Sealed Graffiti | Lambda (Shark White)":           "1711_19",
+		"Sealed Graffiti | Lambda Duplicate (Shark White)": "1711_19",
+		"Sealed Graffiti | Broken":                         "broken",
+	})
+	if _, exists := graffiti["Sealed Graffiti | Broken"]; exists {
+		t.Error("buildGraffiti() kept an unparsable graffiti ID")
+	}
+
+	index := newCatalogIndex(&Catalog{SteamGraffiti: graffiti})
+
+	tests := []struct {
+		name         string
+		stickerKitID int
+		tintID       int
+		want         string
+		found        bool
+	}{
+		{"untinted", 3983, 0, "Sealed Graffiti | Recoil AK-47", true},
+		{"tinted", 1711, 7, "Sealed Graffiti | Lambda (Battle Green)", true},
+		{"duplicate keeps the first name", 1711, 19, "Sealed Graffiti | Lambda (Shark White)", true},
+		{"tinted graffiti without tint", 1711, 0, "", false},
+		{"untinted graffiti with tint", 3983, 7, "", false},
+		{"unknown sticker kit", 1, 0, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, found := index.resolveGraffiti(test.stickerKitID, test.tintID)
+			if name != test.want || found != test.found {
+				t.Errorf("resolveGraffiti(%d, %d) = %q, %t, want %q, %t", test.stickerKitID, test.tintID, name, found, test.want, test.found)
+			}
+		})
+	}
+}
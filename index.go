@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+
+	"steamSkinIDs/pkg/search"
+)
+
+// ItemRecord is everything the catalog knows about one market_hash_name.
+// IDs is keyed by marketplace and then by kind, the same way as the
+// marketplace_ids table of the SQLite database.
+type ItemRecord struct {
+	MarketHashName           string                    `json:"market_hash_name"`
+	IDs                      map[string]map[string]any `json:"ids,omitempty"`
+	Buff163PhaseIDs          map[string]int            `json:"buff163_phase_ids,omitempty"`
+	PhasePaintIndexes        map[string]int            `json:"phase_paint_indexes,omitempty"`
+	Buff163TagIDs            map[string]int            `json:"buff163_tag_ids,omitempty"`
+	Buff163PaintseedGroupIDs map[string]int            `json:"buff163_paintseed_group_ids,omitempty"`
+	Buff163Patterns          map[string][]int          `json:"buff163_patterns,omitempty"`
+}
+
+type IndexEntry struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+}
+
+type catalogIndex struct {
+	catalog      *Catalog
+	items        map[string]*ItemRecord
+	names        []string
+	byID         map[string]map[string][]string
+	byDefIndex   map[int][]IndexEntry
+	byPaintIndex map[int][]IndexEntry
+	byGraffiti   map[graffitiKey]string
+	byStickerKit map[int]string
+	searchIndex  *search.Index
+}
+
+func idKey(marketplace, kind string) string {
+	return marketplace + "/" + kind
+}
+
+func newCatalogIndex(catalog *Catalog) *catalogIndex {
+	index := &catalogIndex{
+		catalog:      catalog,
+		items:        make(map[string]*ItemRecord),
+		names:        catalog.itemNames(),
+		byID:         make(map[string]map[string][]string),
+		byDefIndex:   make(map[int][]IndexEntry),
+		byPaintIndex: make(map[int][]IndexEntry),
+		byGraffiti:   graffitiNames(catalog.SteamGraffiti),
+		byStickerKit: stickerNames(catalog.StickerMetadata),
+		searchIndex:  newSearchIndex(catalog),
+	}
+
+	for _, name := range index.names {
+		index.items[name] = &ItemRecord{MarketHashName: name}
+	}
+
+	for _, source := range catalog.idSources() {
+		key := idKey(source.marketplace, source.kind)
+		byID := make(map[string][]string, len(source.ids))
+		index.byID[key] = byID
+
+		for _, name := range sortedKeys(source.ids) {
+			id := source.ids[name]
+			byID[fmt.Sprint(id)] = append(byID[fmt.Sprint(id)], name)
+
+			item := index.items[name]
+			if item.IDs == nil {
+				item.IDs = make(map[string]map[string]any)
+			}
+			if item.IDs[source.marketplace] == nil {
+				item.IDs[source.marketplace] = make(map[string]any)
+			}
+			item.IDs[source.marketplace][source.kind] = id
+		}
+	}
+
+	for name, ids := range catalog.Buff163PhaseIDs {
+		item := index.items[name]
+		item.Buff163PhaseIDs = ids
+
+		for phase := range ids {
+			if paintIndex, exists := catalog.PhasePaintIndex(name, phase); exists {
+				if item.PhasePaintIndexes == nil {
+					item.PhasePaintIndexes = make(map[string]int)
+				}
+				item.PhasePaintIndexes[phase] = paintIndex
+			}
+		}
+	}
+	for name, ids := range catalog.Buff163TagIDs {
+		index.items[name].Buff163TagIDs = ids
+	}
+	for name, ids := range catalog.Buff163PaintseedGroupIDs {
+		index.items[name].Buff163PaintseedGroupIDs = ids
+	}
+	for name, tiers := range catalog.Buff163Patterns {
+		index.items[name].Buff163Patterns = tiers
+	}
+
+	for _, name := range sortedKeys(catalog.DefIndexes) {
+		defIndex := catalog.DefIndexes[name]
+		index.byDefIndex[defIndex] = append(index.byDefIndex[defIndex], IndexEntry{Name: name, Index: defIndex})
+	}
+	for _, name := range sortedKeys(catalog.PaintIndexes) {
+		paintIndex := catalog.PaintIndexes[name]
+		index.byPaintIndex[paintIndex] = append(index.byPaintIndex[paintIndex], IndexEntry{Name: name, Index: paintIndex})
+	}
+
+	return index
+}
+
+func (index *catalogIndex) item(name string) *ItemRecord {
+	return index.items[name]
+}
+
+// resolveGraffiti returns the market_hash_name of a graffiti from the
+// "sticker slot 0 id" and "spray tint id" attributes of an inventory item.
+// A tint ID of 0 means the graffiti is not tinted.
+func (index *catalogIndex) resolveGraffiti(stickerKitID int, tintID int) (string, bool) {
+	name, exists := index.byGraffiti[graffitiKey{stickerKitID: stickerKitID, tintID: tintID}]
+	return name, exists
+}
+
+// stickerByKit returns the market_hash_name and metadata of the sticker with
+// the given sticker kit ID, as found in the "sticker slot" attributes of
+// inventory items.
+func (index *catalogIndex) stickerByKit(stickerKitID int) (string, StickerMetadata, bool) {
+	name, exists := index.byStickerKit[stickerKitID]
+	if !exists {
+		return "", StickerMetadata{}, false
+	}
+	return name, index.catalog.StickerMetadata[name], true
+}
+
+func (index *catalogIndex) itemsByID(marketplace, kind, id string) []*ItemRecord {
+	names := index.byID[idKey(marketplace, kind)][id]
+
+	items := make([]*ItemRecord, 0, len(names))
+	for _, name := range names {
+		items = append(items, index.items[name])
+	}
+
+	return items
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"steamSkinIDs/pkg/itemname"
+)
+
+const counterStrikeAppID = 730
+
+// SteamInventory is a saved response of the Steam inventory endpoint,
+// https://steamcommunity.com/inventory/{steamid}/730/2.
+type SteamInventory struct {
+	Assets []struct {
+		AppID      int    `json:"appid"`
+		ContextID  string `json:"contextid"`
+		AssetID    string `json:"assetid"`
+		ClassID    string `json:"classid"`
+		InstanceID string `json:"instanceid"`
+		Amount     string `json:"amount"`
+	} `json:"assets"`
+	Descriptions []struct {
+		AppID          int    `json:"appid"`
+		ClassID        string `json:"classid"`
+		InstanceID     string `json:"instanceid"`
+		MarketHashName string `json:"market_hash_name"`
+		Descriptions   []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+			Name  string `json:"name"`
+		} `json:"descriptions"`
+	} `json:"descriptions"`
+}
+
+// InventoryAsset is an asset of a Steam inventory annotated with the catalog.
+type InventoryAsset struct {
+	AssetID        string                    `json:"assetid"`
+	ClassID        string                    `json:"classid"`
+	InstanceID     string                    `json:"instanceid"`
+	MarketHashName string                    `json:"market_hash_name"`
+	DefIndex       *int                      `json:"def_index,omitempty"`
+	PaintIndex     *int                      `json:"paint_index,omitempty"`
+	IDs            map[string]map[string]any `json:"ids,omitempty"`
+	Stickers       []AppliedItem             `json:"stickers,omitempty"`
+	Keychains      []AppliedItem             `json:"keychains,omitempty"`
+	Patches        []AppliedItem             `json:"patches,omitempty"`
+}
+
+// AppliedItem is a sticker, keychain or patch applied to an asset.
+type AppliedItem struct {
+	MarketHashName string                    `json:"market_hash_name"`
+	IDs            map[string]map[string]any `json:"ids,omitempty"`
+}
+
+// appliedItemPattern matches the "Sticker: A, B" lines Steam puts in the
+// html descriptions of items with stickers, keychains or patches applied.
+var appliedItemPattern = regexp.MustCompile(`(Sticker|Charm|Patch): ([^<]+)`)
+
+var appliedItemPrefixes = map[string]string{
+	"Sticker": "Sticker | ",
+	"Charm":   "Charm | ",
+	"Patch":   "Patch | ",
+}
+
+// splitAppliedNames splits a comma separated list of applied item names,
+// keeping commas that are part of a known name.
+func (index *catalogIndex) splitAppliedNames(prefix string, list string) []string {
+	parts := strings.Split(list, ", ")
+
+	var names []string
+	for i := 0; i < len(parts); {
+		end := i + 1
+		for j := len(parts); j > i+1; j-- {
+			if index.item(prefix+strings.Join(parts[i:j], ", ")) != nil {
+				end = j
+				break
+			}
+		}
+		names = append(names, prefix+strings.Join(parts[i:end], ", "))
+		i = end
+	}
+
+	return names
+}
+
+func (index *catalogIndex) appliedItem(name string) AppliedItem {
+	applied := AppliedItem{MarketHashName: name}
+	if item := index.item(name); item != nil {
+		applied.IDs = item.IDs
+	}
+	return applied
+}
+
+// annotateInventory joins every Counter-Strike asset of an inventory with its
+// description and the catalog.
+func (index *catalogIndex) annotateInventory(inventory *SteamInventory) []InventoryAsset {
+	descriptions := make(map[string]int, len(inventory.Descriptions))
+	for i, description := range inventory.Descriptions {
+		descriptions[description.ClassID+"_"+description.InstanceID] = i
+	}
+
+	assets := make([]InventoryAsset, 0, len(inventory.Assets))
+
+	for _, asset := range inventory.Assets {
+		if asset.AppID != counterStrikeAppID {
+			continue
+		}
+
+		annotated := InventoryAsset{
+			AssetID:    asset.AssetID,
+			ClassID:    asset.ClassID,
+			InstanceID: asset.InstanceID,
+		}
+
+		i, exists := descriptions[asset.ClassID+"_"+asset.InstanceID]
+		if !exists {
+			assets = append(assets, annotated)
+			continue
+		}
+		description := inventory.Descriptions[i]
+		name := description.MarketHashName
+		annotated.MarketHashName = name
+
+		if item := index.item(name); item != nil {
+			annotated.IDs = item.IDs
+		}
+
+		skin := itemname.SkinName(name)
+		weapon, _, _ := strings.Cut(skin, " | ")
+		if defIndex, exists := index.catalog.DefIndexes[weapon]; exists {
+			annotated.DefIndex = &defIndex
+		}
+		if paintIndex, exists := index.catalog.PaintIndexes[skin]; exists {
+			annotated.PaintIndex = &paintIndex
+		}
+
+		for _, line := range description.Descriptions {
+			for _, match := range appliedItemPattern.FindAllStringSubmatch(line.Value, -1) {
+				prefix := appliedItemPrefixes[match[1]]
+				for _, appliedName := range index.splitAppliedNames(prefix, strings.TrimSpace(match[2])) {
+					applied := index.appliedItem(appliedName)
+					switch match[1] {
+					case "Sticker":
+						annotated.Stickers = append(annotated.Stickers, applied)
+					case "Charm":
+						annotated.Keychains = append(annotated.Keychains, applied)
+					case "Patch":
+						annotated.Patches = append(annotated.Patches, applied)
+					}
+				}
+			}
+		}
+
+		assets = append(assets, annotated)
+	}
+
+	return assets
+}
+
+func runInventory(args []string) error {
+	flags := flag.NewFlagSet("inventory", flag.ExitOnError)
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: inventory <inventory.json|->")
+	}
+
+	var input io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("Failed to open inventory %s: %w", path, err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var inventory SteamInventory
+	if err := json.NewDecoder(input).Decode(&inventory); err != nil {
+		return fmt.Errorf("Failed to decode inventory: %w", err)
+	}
+
+	catalog, err := loadCatalog(*dir)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+
+	return encoder.Encode(newCatalogIndex(catalog).annotateInventory(&inventory))
+}
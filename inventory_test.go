@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func newInventoryTestIndex() *catalogIndex {
+	return newCatalogIndex(&Catalog{
+		SteamMarketIDs: map[string]int{"AK-47 | Redline (Field-Tested)": 49},
+		SteamStickerIDs: map[string]int{
+			"Sticker | Don't Worry":                                1,
+			"Sticker | Don't Worry, I'm Pro":                       2,
+			"Sticker | Crown (Foil)":                               3,
+			"Sticker | Aleksib (Holo, Champion) | Copenhagen 2024": 4,
+			"Sticker | Aleksib (Gold, Champion) | Copenhagen 2024": 5,
+		},
+		SteamKeychainIDs: map[string]int{"Charm | Lil' Squirt": 6},
+		SteamPatchIDs:    map[string]int{"Patch | Metal The Boss": 7},
+		DefIndexes:       map[string]int{"AK-47": 7, "Sport Gloves": 5030},
+		PaintIndexes:     map[string]int{"AK-47 | Redline": 282, "Sport Gloves | Vice": 10048},
+	})
+}
+
+func TestSplitAppliedNames(t *testing.T) {
+	index := newInventoryTestIndex()
+
+	tests := []struct {
+		name string
+		list string
+		want []string
+	}{
+		{
+			name: "single name",
+			list: "Crown (Foil)",
+			want: []string{"Sticker | Crown (Foil)"},
+		},
+		{
+			name: "name with a comma",
+			list: "Don't Worry, I'm Pro",
+			want: []string{"Sticker | Don't Worry, I'm Pro"},
+		},
+		{
+			name: "prefix of a longer name",
+			list: "Don't Worry, Crown (Foil)",
+			want: []string{"Sticker | Don't Worry", "Sticker | Crown (Foil)"},
+		},
+		{
+			name: "champion finishes",
+			list: "Aleksib (Holo, Champion) | Copenhagen 2024, Aleksib (Gold, Champion) | Copenhagen 2024, Don't Worry, I'm Pro",
+			want: []string{
+				"Sticker | Aleksib (Holo, Champion) | Copenhagen 2024",
+				"Sticker | Aleksib (Gold, Champion) | Copenhagen 2024",
+				"Sticker | Don't Worry, I'm Pro",
+			},
+		},
+		{
+			name: "unknown names are split on every comma",
+			list: "Unknown, Other (Foil)",
+			want: []string{"Sticker | Unknown", "Sticker | Other (Foil)"},
+		},
+		{
+			name: "repeated sticker",
+			list: "Crown (Foil), Crown (Foil)",
+			want: []string{"Sticker | Crown (Foil)", "Sticker | Crown (Foil)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := index.splitAppliedNames("Sticker | ", test.list); !slices.Equal(got, test.want) {
+				t.Errorf("splitAppliedNames(%q) = %q, want %q", test.list, got, test.want)
+			}
+		})
+	}
+}
+
+// Description values as saved from the Steam inventory endpoint.
+const (
+	stickerInfoHTML = `<br><div id="sticker_info" name="sticker_info" title="Sticker" style="border: 2px solid rgb(102, 102, 102); border-radius: 6px; width=100; margin:4px; padding:8px;"><center><img width=64 height=48 src="https://steamcdn-a.akamaihd.net/apps/730/icons/econ/stickers/comm01/dontworryimpro.png"><img width=64 height=48 src="https://steamcdn-a.akamaihd.net/apps/730/icons/econ/stickers/cph2024/sig_aleksib_holo.png"><br>Sticker: Don't Worry, I'm Pro, Aleksib (Holo, Champion) | Copenhagen 2024</center></div>`
+	charmInfoHTML   = `<br><div id="keychain_info" name="keychain_info" title="Charm" style="border: 2px solid rgb(102, 102, 102); border-radius: 6px; width=100; margin:4px; padding:8px;"><center><img width=64 height=48 src="https://steamcdn-a.akamaihd.net/apps/730/icons/econ/keychains/missinglink/lil_squirt.png"><br>Charm: Lil' Squirt</center></div>`
+	patchInfoHTML   = `<br><div id="sticker_info" name="sticker_info" title="Patch" style="border: 2px solid rgb(102, 102, 102); border-radius: 6px; width=100; margin:4px; padding:8px;"><center><img width=64 height=48 src="https://steamcdn-a.akamaihd.net/apps/730/icons/econ/patches/metal_theboss.png"><br>Patch: Metal The Boss</center></div>`
+)
+
+func TestAnnotateInventory(t *testing.T) {
+	index := newInventoryTestIndex()
+
+	// The saved response has the shape of the Steam inventory endpoint,
+	// including an asset without a description and one of another app.
+	response := map[string]any{
+		"assets": []map[string]any{
+			{"appid": 730, "contextid": "2", "assetid": "1", "classid": "100", "instanceid": "0", "amount": "1"},
+			{"appid": 730, "contextid": "2", "assetid": "2", "classid": "200", "instanceid": "0", "amount": "1"},
+			{"appid": 730, "contextid": "2", "assetid": "3", "classid": "300", "instanceid": "0", "amount": "1"},
+			{"appid": 730, "contextid": "2", "assetid": "4", "classid": "999", "instanceid": "0", "amount": "1"},
+			{"appid": 753, "contextid": "6", "assetid": "5", "classid": "100", "instanceid": "0", "amount": "1"},
+		},
+		"descriptions": []map[string]any{
+			{"appid": 730, "classid": "100", "instanceid": "0", "market_hash_name": "AK-47 | Redline (Field-Tested)", "descriptions": []map[string]any{
+				{"type": "html", "value": "Exterior: Field-Tested", "name": "exterior_wear"},
+				{"type": "html", "value": stickerInfoHTML, "name": "sticker_info"},
+				{"type": "html", "value": charmInfoHTML, "name": "keychain_info"},
+			}},
+			{"appid": 730, "classid": "200", "instanceid": "0", "market_hash_name": "★ Sport Gloves | Vice (Minimal Wear)", "descriptions": []map[string]any{
+				{"type": "html", "value": patchInfoHTML, "name": "sticker_info"},
+			}},
+			{"appid": 730, "classid": "300", "instanceid": "0", "market_hash_name": "Sticker | Crown (Foil)"},
+		},
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inventory SteamInventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		t.Fatal(err)
+	}
+
+	defIndex, paintIndex := 7, 282
+	glovesDefIndex, glovesPaintIndex := 5030, 10048
+	want := []InventoryAsset{
+		{
+			AssetID:        "1",
+			ClassID:        "100",
+			InstanceID:     "0",
+			MarketHashName: "AK-47 | Redline (Field-Tested)",
+			DefIndex:       &defIndex,
+			PaintIndex:     &paintIndex,
+			IDs:            map[string]map[string]any{"steam": {"market": 49}},
+			Stickers: []AppliedItem{
+				{MarketHashName: "Sticker | Don't Worry, I'm Pro", IDs: map[string]map[string]any{"steam": {"stickers": 2}}},
+				{MarketHashName: "Sticker | Aleksib (Holo, Champion) | Copenhagen 2024", IDs: map[string]map[string]any{"steam": {"stickers": 4}}},
+			},
+			Keychains: []AppliedItem{
+				{MarketHashName: "Charm | Lil' Squirt", IDs: map[string]map[string]any{"steam": {"keychains": 6}}},
+			},
+		},
+		{
+			AssetID:        "2",
+			ClassID:        "200",
+			InstanceID:     "0",
+			MarketHashName: "★ Sport Gloves | Vice (Minimal Wear)",
+			DefIndex:       &glovesDefIndex,
+			PaintIndex:     &glovesPaintIndex,
+			Patches: []AppliedItem{
+				{MarketHashName: "Patch | Metal The Boss", IDs: map[string]map[string]any{"steam": {"patches": 7}}},
+			},
+		},
+		{
+			AssetID:        "3",
+			ClassID:        "300",
+			InstanceID:     "0",
+			MarketHashName: "Sticker | Crown (Foil)",
+			IDs:            map[string]map[string]any{"steam": {"stickers": 3}},
+		},
+		{AssetID: "4", ClassID: "999", InstanceID: "0"},
+	}
+
+	got := index.annotateInventory(&inventory)
+	if len(got) != len(want) {
+		t.Fatalf("annotateInventory() returned %d assets, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("asset %s = %+v, want %+v", want[i].AssetID, got[i], want[i])
+		}
+	}
+}
+
+func TestAppliedItemPattern(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want [][]string
+	}{
+		{name: "stickers", html: stickerInfoHTML, want: [][]string{{"Sticker", "Don't Worry, I'm Pro, Aleksib (Holo, Champion) | Copenhagen 2024"}}},
+		{name: "charm", html: charmInfoHTML, want: [][]string{{"Charm", "Lil' Squirt"}}},
+		{name: "patch", html: patchInfoHTML, want: [][]string{{"Patch", "Metal The Boss"}}},
+		{name: "plain description", html: "Exterior: Field-Tested", want: nil},
+		{name: "empty", html: "", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got [][]string
+			for _, match := range appliedItemPattern.FindAllStringSubmatch(test.html, -1) {
+				got = append(got, match[1:])
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matches = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HostLimit throttles the requests to one host. RequestsPerSecond and Burst
+// configure a token bucket, MaxConcurrency caps the requests in flight,
+// counting a request until its body is closed. Zero disables a limit.
+type HostLimit struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+	MaxConcurrency    int     `json:"max_concurrency"`
+}
+
+// tokenBucket allows burst requests at once and refills at rate per second.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	// Taking the token up front reserves it, a negative balance queues the
+	// waiters behind each other.
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+type hostLimiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+// limitedTransport applies the HostLimit of the request host before passing
+// the request on to base.
+type limitedTransport struct {
+	base  http.RoundTripper
+	hosts map[string]*hostLimiter
+}
+
+func newLimitedTransport(base http.RoundTripper, limits map[string]HostLimit) *limitedTransport {
+	hosts := make(map[string]*hostLimiter, len(limits))
+	for host, limit := range limits {
+		limiter := &hostLimiter{}
+		if limit.RequestsPerSecond > 0 {
+			limiter.bucket = newTokenBucket(limit.RequestsPerSecond, limit.Burst)
+		}
+		if limit.MaxConcurrency > 0 {
+			limiter.slots = make(chan struct{}, limit.MaxConcurrency)
+		}
+		hosts[host] = limiter
+	}
+	return &limitedTransport{base: base, hosts: hosts}
+}
+
+func (t *limitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	limiter, exists := t.hosts[request.URL.Hostname()]
+	if !exists {
+		return t.base.RoundTrip(request)
+	}
+
+	ctx := request.Context()
+
+	if limiter.slots != nil {
+		select {
+		case limiter.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if limiter.slots != nil {
+			<-limiter.slots
+		}
+	}
+
+	if limiter.bucket != nil {
+		if err := limiter.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	response, err := t.base.RoundTrip(request)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	response.Body = &releasingBody{ReadCloser: response.Body, release: release}
+
+	return response, nil
+}
+
+// releasingBody frees the concurrency slot of its request once closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
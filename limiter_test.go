@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurst(t *testing.T) {
+	bucket := newTokenBucket(20, 3)
+
+	start := time.Now()
+	for range 3 {
+		if err := bucket.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("burst of 3 took %s, want no wait", elapsed)
+	}
+
+	start = time.Now()
+	if err := bucket.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("request after the burst waited %s, want about 50ms", elapsed)
+	}
+}
+
+func TestTokenBucketRefund(t *testing.T) {
+	bucket := newTokenBucket(1, 1)
+	if err := bucket.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait() error = %v, want the deadline", err)
+	}
+
+	// The cancelled waiter gave its token back, so the balance is what it
+	// was before it queued instead of another second in debt.
+	bucket.mu.Lock()
+	tokens := bucket.tokens
+	bucket.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("tokens = %.2f after a cancelled wait, want the token refunded", tokens)
+	}
+}
+
+// stubTransport answers every request with an empty body and counts them.
+func stubTransport(requests chan<- string) http.RoundTripper {
+	return roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if requests != nil {
+			requests <- request.URL.Hostname()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+}
+
+func newRequest(t *testing.T, ctx context.Context, url string) *http.Request {
+	t.Helper()
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return request
+}
+
+func TestLimitedTransportConcurrency(t *testing.T) {
+	transport := newLimitedTransport(stubTransport(nil), map[string]HostLimit{
+		"limited.example": {MaxConcurrency: 1},
+	})
+
+	first, err := transport.RoundTrip(newRequest(t, context.Background(), "https://limited.example/a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := newRequest(t, context.Background(), "https://limited.example/b.json")
+	done := make(chan error, 1)
+	go func() {
+		response, err := transport.RoundTrip(second)
+		if err == nil {
+			response.Body.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second request ran while the first body was open")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first.Body.Close()
+	// Closing twice must not free a second slot.
+	first.Body.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second request still blocked after the first body was closed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	held, err := transport.RoundTrip(newRequest(t, ctx, "https://limited.example/c.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Body.Close()
+
+	cancel()
+	if _, err := transport.RoundTrip(newRequest(t, ctx, "https://limited.example/d.json")); !errors.Is(err, context.Canceled) {
+		t.Errorf("RoundTrip() error = %v while waiting for a slot, want the cancellation", err)
+	}
+}
+
+func TestLimitedTransportUnlistedHost(t *testing.T) {
+	requests := make(chan string, 2)
+	transport := newLimitedTransport(stubTransport(requests), map[string]HostLimit{
+		"limited.example": {RequestsPerSecond: 0.001, Burst: 1, MaxConcurrency: 1},
+	})
+
+	held, err := transport.RoundTrip(newRequest(t, context.Background(), "https://limited.example/a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Body.Close()
+
+	// The limited host has no slot and no token left, the other host is
+	// neither counted nor throttled.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	response, err := transport.RoundTrip(newRequest(t, ctx, "https://other.example/b.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if _, wrapped := response.Body.(*releasingBody); wrapped {
+		t.Error("response of an unlisted host holds a concurrency slot")
+	}
+	if got := []string{<-requests, <-requests}; got[0] != "limited.example" || got[1] != "other.example" {
+		t.Errorf("requests = %q", got)
+	}
+}
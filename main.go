@@ -5,35 +5,49 @@ import (
 	"compress/zlib"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
-	"regexp"
+	"os/signal"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
+
+	"steamSkinIDs/pkg/marketids"
 )
 
+// The base URLs are built from the repository and branch of their upstream,
+// which are also what the lock file pins, see upstreams.
 const (
-	byMykelAPIBaseURL = "https://raw.githubusercontent.com/ByMykel/CSGO-API/main/public/api/en/"
+	byMykelRepo       = "ByMykel/CSGO-API"
+	byMykelRef        = "main"
+	byMykelAPIBaseURL = rawGitHubBaseURL + byMykelRepo + "/" + byMykelRef + "/public/api/en/"
 
-	ericZhuAPIBaseURL = "https://raw.githubusercontent.com/EricZhu-42/SteamTradingSite-ID-Mapper/main/"
+	ericZhuRepo       = "EricZhu-42/SteamTradingSite-ID-Mapper"
+	ericZhuRef        = "main"
+	ericZhuAPIBaseURL = rawGitHubBaseURL + ericZhuRepo + "/" + ericZhuRef + "/"
 	counterStrikeJSON = "/730.json"
 
-	modestSerhatAPIBaseURL = "https://raw.githubusercontent.com/ModestSerhat/cs2-marketplace-ids/main/"
+	modestSerhatRepo       = "ModestSerhat/cs2-marketplace-ids"
+	modestSerhatRef        = "main"
+	modestSerhatAPIBaseURL = rawGitHubBaseURL + modestSerhatRepo + "/" + modestSerhatRef + "/"
 )
 
 var (
-	ctx = context.Background()
-
+	// Requests are bounded by the context of their stage instead of a
+	// client timeout, see runStages.
 	defaultHttpClient = &http.Client{
-		Timeout: 10 * time.Second,
 		Transport: &http.Transport{
 			MaxIdleConns:        100,
 			MaxIdleConnsPerHost: 100,
@@ -48,8 +62,6 @@ var (
 		"Connection":      {"keep-alive"},
 		"Priority":        {"u=1"},
 	}
-
-	defIndexes map[string]int
 )
 
 type ModestSerhatResponse struct {
@@ -257,116 +269,55 @@ type Patch struct {
 	Image          string `json:"image"`
 }
 
-type Skin struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Weapon      struct {
-		ID       string `json:"id"`
-		WeaponID int    `json:"weapon_id"`
-		Name     string `json:"name"`
-	} `json:"weapon"`
-	Category struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"category"`
-	Pattern *struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"pattern"`
-	MinFloat *float64 `json:"min_float"`
-	MaxFloat *float64 `json:"max_float"`
-	Rarity   struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Color string `json:"color"`
-	} `json:"rarity"`
-	Stattrak   bool    `json:"stattrak"`
-	Souvenir   bool    `json:"souvenir"`
-	PaintIndex *string `json:"paint_index"`
-	Wears      []struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"wears"`
-	Collections []struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Image string `json:"image"`
-	} `json:"collections"`
-	Crates []struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Image string `json:"image"`
-	} `json:"crates"`
-	Team struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"team"`
-	LegacyModel  bool    `json:"legacy_model"`
-	Image        string  `json:"image"`
-	Phase        *string `json:"phase"`
-	SpecialNotes []struct {
-		Source string `json:"source"`
-		Text   string `json:"text"`
-	} `json:"special_notes"`
-}
-
-type Sticker struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	DefIndex    string `json:"def_index"`
-	Rarity      struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Color string `json:"color"`
-	} `json:"rarity"`
-	Crates []struct {
-		ID    string `json:"id"`
-		Name  string `json:"name"`
-		Image string `json:"image"`
-	} `json:"crates"`
-	TournamentEvent  string  `json:"tournament_event"`
-	Type             string  `json:"type"`
-	MarketHashName   *string `json:"market_hash_name"`
-	Effect           string  `json:"effect"`
-	Image            string  `json:"image"`
-	TournamentTeam   string  `json:"tournament_team"`
-	TournamentPlayer string  `json:"tournament_player"`
-	SpecialNotes     []struct {
-		Source string `json:"source"`
-		Text   string `json:"text"`
-	} `json:"special_notes"`
+func getRequest(ctx context.Context, url string, target any) error {
+	return getStream(ctx, url, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(target)
+	})
 }
 
-func getRequest(ctx context.Context, url string, target any) error {
-	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// getStream passes the decompressed response body to decode, which can read
+// it incrementally instead of materializing the whole payload.
+func getStream(ctx context.Context, url string, decode func(r io.Reader) error) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", pinURL(url), nil)
 	if err != nil {
-		return fmt.Errorf("Failed to create request for URL %s: %w", url, err)
+		return &FetchError{FetchNetwork, url, fmt.Errorf("Failed to create request for URL %s: %w", url, err)}
 	}
 
 	request.Header = defaultHeaders.Clone()
 
 	response, err := defaultHttpClient.Do(request)
 	if err != nil {
-		return fmt.Errorf("Request execution failed for URL %s: %w", url, err)
+		return &FetchError{FetchNetwork, url, fmt.Errorf("Request execution failed for URL %s: %w", url, err)}
 	}
 
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("Unexpected status code for URL %s: %d", url, response.StatusCode)
+		return &FetchError{FetchStatus, url, fmt.Errorf("Unexpected status code for URL %s: %d", url, response.StatusCode)}
+	}
+
+	if err := checkContentType(response.Header.Get("Content-Type")); err != nil {
+		return &FetchError{FetchValidation, url, fmt.Errorf("Unexpected response for URL %s: %w", url, err)}
 	}
 
 	bodyReader, err := getDecompressedBody(response)
 	if err != nil {
-		return fmt.Errorf("Failed to get decompressed body from response for URL %s: %w", url, err)
+		return &FetchError{FetchDecode, url, fmt.Errorf("Failed to get decompressed body from response for URL %s: %w", url, err)}
 	}
 
 	defer bodyReader.Close()
 
-	if err := json.NewDecoder(bodyReader).Decode(target); err != nil {
-		return fmt.Errorf("Failed to unmarshal response body for URL %s: %w", url, err)
+	limit := limitFor(url)
+	body := &maxBytesReader{r: bodyReader, remaining: limit.maxBodySize}
+
+	if err := decode(body); err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			return &FetchError{FetchValidation, url, fmt.Errorf("Response body for URL %s exceeds %d bytes: %w", url, limit.maxBodySize, err)}
+		}
+		if ctx.Err() != nil {
+			return &FetchError{FetchNetwork, url, fmt.Errorf("Failed to read response body for URL %s: %w", url, err)}
+		}
+		return &FetchError{FetchDecode, url, fmt.Errorf("Failed to unmarshal response body for URL %s: %w", url, err)}
 	}
 
 	return nil
@@ -404,58 +355,26 @@ func getDecompressedBody(response *http.Response) (io.ReadCloser, error) {
 	}
 }
 
-func getSteamIndexes(endpoint string) (map[string]int, map[string]int, error) {
+func getSteamIndexes(ctx context.Context, endpoint string) (map[string]int, map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
-	var data []Skin
-	if err := getRequest(ctx, url, &data); err != nil {
+	var defIndexes, paintIndexes map[string]int
+	err := getStream(ctx, url, func(r io.Reader) (err error) {
+		defIndexes, paintIndexes, err = decodeSteamIndexes(r)
+		return err
+	})
+	if err != nil {
 		return nil, nil, fmt.Errorf("Failed to fetch steam indexes. %w", err)
 	}
 
-	defIndexes := make(map[string]int, len(data))
-	paintIndexes := make(map[string]int, len(data))
-
-	for _, item := range data {
-		defName := item.Weapon.Name
-
-		defIndexes[defName] = item.Weapon.WeaponID
-
-		if item.PaintIndex == nil {
-			continue
-		}
-
-		paintIndex, err := strconv.Atoi(*item.PaintIndex)
-		if err != nil {
-			continue
-		}
-
-		paint := item.Pattern.Name
-
-		var baseKeyBuilder strings.Builder
-		baseKeyBuilder.Grow(len(defName) + 3 + len(paint))
-		baseKeyBuilder.WriteString(defName)
-		baseKeyBuilder.WriteString(" | ")
-		baseKeyBuilder.WriteString(paint)
-		baseKey := baseKeyBuilder.String()
-
-		if item.Phase != nil {
-			var keyBuilder strings.Builder
-			keyBuilder.Grow(len(baseKey) + 1 + len(*item.Phase))
-			keyBuilder.WriteString(baseKey)
-			keyBuilder.WriteByte(' ')
-			keyBuilder.WriteString(*item.Phase)
-			phaseKey := keyBuilder.String()
-
-			paintIndexes[phaseKey] = paintIndex
-		} else {
-			paintIndexes[baseKey] = paintIndex
-		}
+	if err := checkMinItems(url, len(paintIndexes)); err != nil {
+		return nil, nil, err
 	}
 
 	return defIndexes, paintIndexes, nil
 }
 
-func getSteamAgentIDs(endpoint string) (map[string]int, error) {
+func getSteamAgentIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Agent
@@ -473,10 +392,14 @@ func getSteamAgentIDs(endpoint string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamCollectibleIDs(endpoint string) (map[string]int, error) {
+func getSteamCollectibleIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Collectible
@@ -497,10 +420,14 @@ func getSteamCollectibleIDs(endpoint string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamCrateIDs(endpoint string) (map[string]int, error) {
+func getSteamCrateIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Crate
@@ -509,25 +436,24 @@ func getSteamCrateIDs(endpoint string) (map[string]int, error) {
 	}
 
 	ids := make(map[string]int, len(data))
-	excludedPattern := `\b(Sticker Collection|Patch Collection|Storage Unit)\b`
 
 	for _, item := range data {
 		idParts := strings.Split(item.ID, "-")
 
 		id, err := strconv.Atoi(idParts[1])
 		if err == nil {
-			marketHashName := item.MarketHashName
-			isExcluded, _ := regexp.MatchString(excludedPattern, marketHashName)
-			if !isExcluded {
-				ids[marketHashName] = id
-			}
+			ids[item.MarketHashName] = id
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamGraffitiIDs(endpoint string) (map[string]string, error) {
+func getSteamGraffitiIDs(ctx context.Context, endpoint string) (map[string]string, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Graffiti
@@ -546,10 +472,14 @@ func getSteamGraffitiIDs(endpoint string) (map[string]string, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamHighlightIDs(endpoint string) (map[string]string, error) {
+func getSteamHighlightIDs(ctx context.Context, endpoint string) (map[string]string, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Highlight
@@ -563,10 +493,14 @@ func getSteamHighlightIDs(endpoint string) (map[string]string, error) {
 		ids[item.MarketHashName] = item.ID
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamKeychainIDs(endpoint string) (map[string]int, error) {
+func getSteamKeychainIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Keychain
@@ -586,10 +520,35 @@ func getSteamKeychainIDs(endpoint string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamKeyIDs(endpoint string) (map[string]any, error) {
+// KeyRecord is the typed form of a key. ID is the part of the ByMykel ID
+// after "key-", DefIndex is set when that part is numeric.
+type KeyRecord struct {
+	ID         string   `json:"id"`
+	DefIndex   *int     `json:"def_index"`
+	Marketable bool     `json:"marketable"`
+	Crates     []string `json:"crates"`
+}
+
+func (KeyRecord) columns() []string {
+	return []string{"id", "def_index", "marketable", "crates"}
+}
+
+func (key KeyRecord) rows() [][]string {
+	defIndex := ""
+	if key.DefIndex != nil {
+		defIndex = strconv.Itoa(*key.DefIndex)
+	}
+	return [][]string{{key.ID, defIndex, strconv.FormatBool(key.Marketable), strings.Join(key.Crates, ";")}}
+}
+
+func getSteamKeyIDs(ctx context.Context, endpoint string) (map[string]KeyRecord, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Key
@@ -597,7 +556,7 @@ func getSteamKeyIDs(endpoint string) (map[string]any, error) {
 		return nil, fmt.Errorf("Failed to fetch steam ids. %w", err)
 	}
 
-	ids := make(map[string]any, len(data))
+	keys := make(map[string]KeyRecord, len(data))
 
 	for _, item := range data {
 		marketHashName := item.MarketHashName
@@ -605,19 +564,53 @@ func getSteamKeyIDs(endpoint string) (map[string]any, error) {
 		if marketHashName != nil {
 			idParts := strings.Split(item.ID, "-")
 
+			record := KeyRecord{
+				ID:         idParts[1],
+				Marketable: item.Marketable,
+				Crates:     make([]string, 0, len(item.Crates)),
+			}
+
 			id, err := strconv.Atoi(idParts[1])
 			if err == nil {
-				ids[*marketHashName] = id
-			} else {
-				ids[*marketHashName] = idParts[1]
+				record.DefIndex = &id
+			}
+
+			for _, crate := range item.Crates {
+				record.Crates = append(record.Crates, crate.Name)
 			}
+			sort.Strings(record.Crates)
+
+			keys[*marketHashName] = record
 		}
 	}
 
-	return ids, nil
+	if err := checkMinItems(url, len(keys)); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// legacyKeyIDs produces the keys.json layout, where a key maps to its numeric
+// ID when it has one and to its string ID otherwise.
+func legacyKeyIDs(keys map[string]KeyRecord) map[string]any {
+	if keys == nil {
+		return nil
+	}
+
+	ids := make(map[string]any, len(keys))
+	for name, key := range keys {
+		if key.DefIndex != nil {
+			ids[name] = *key.DefIndex
+		} else {
+			ids[name] = key.ID
+		}
+	}
+
+	return ids
 }
 
-func getSteamMusicKitIDs(endpoint string) (map[string]int, error) {
+func getSteamMusicKitIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []MusicKit
@@ -638,10 +631,14 @@ func getSteamMusicKitIDs(endpoint string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamPatchIDs(endpoint string) (map[string]int, error) {
+func getSteamPatchIDs(ctx context.Context, endpoint string) (map[string]int, error) {
 	url := byMykelAPIBaseURL + endpoint
 
 	var data []Patch
@@ -659,50 +656,54 @@ func getSteamPatchIDs(endpoint string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getSteamStickerIDs(endpoint string) (map[string]int, error) {
-	url := byMykelAPIBaseURL + endpoint
+// StickerMetadata is what ByMykel knows about a sticker beyond its ID, for
+// grouping stickers by event, team, player and finish.
+type StickerMetadata struct {
+	StickerKitID     int      `json:"sticker_kit_id"`
+	Type             string   `json:"type"`
+	Effect           string   `json:"effect"`
+	TournamentEvent  string   `json:"tournament_event,omitempty"`
+	TournamentTeam   string   `json:"tournament_team,omitempty"`
+	TournamentPlayer string   `json:"tournament_player,omitempty"`
+	Crates           []string `json:"crates"`
+}
 
-	var data []Sticker
-	if err := getRequest(ctx, url, &data); err != nil {
-		return nil, fmt.Errorf("Failed to fetch steam ids. %w", err)
-	}
+func (StickerMetadata) columns() []string {
+	return []string{"sticker_kit_id", "type", "effect", "tournament_event", "tournament_team", "tournament_player", "crates"}
+}
 
-	excludedNames := map[string]struct{}{
-		"Sticker | 3DMAX | DreamHack 2014":             {},
-		"Sticker | London Conspiracy | DreamHack 2014": {},
-		"Sticker | dAT team | DreamHack 2014":          {},
-		"Sticker | mousesports | DreamHack 2014":       {},
-		"Sticker | Ninja (Foil)":                       {},
-		"Sticker | The Bomber (Foil)":                  {},
-		"Sticker | The Nader (Foil)":                   {},
-		"Sticker | The Awper (Foil)":                   {},
-		"Sticker | The Fragger (Foil)":                 {},
-		"Sticker | Support (Foil)":                     {},
-		"Sticker | The Leader (Foil)":                  {},
-	}
+func (sticker StickerMetadata) rows() [][]string {
+	return [][]string{{strconv.Itoa(sticker.StickerKitID), sticker.Type, sticker.Effect, sticker.TournamentEvent, sticker.TournamentTeam, sticker.TournamentPlayer, strings.Join(sticker.Crates, ";")}}
+}
 
-	ids := make(map[string]int, len(data))
+func getSteamStickerIDs(ctx context.Context, endpoint string) (map[string]int, map[string]StickerMetadata, error) {
+	url := byMykelAPIBaseURL + endpoint
 
-	for _, item := range data {
-		id, err := strconv.Atoi(item.DefIndex)
+	var ids map[string]int
+	var metadata map[string]StickerMetadata
+	err := getStream(ctx, url, func(r io.Reader) (err error) {
+		ids, metadata, err = decodeSteamStickers(r)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to fetch steam ids. %w", err)
+	}
 
-		if err == nil {
-			marketHashName := item.MarketHashName
-			if marketHashName != nil {
-				if _, isExcluded := excludedNames[*marketHashName]; !isExcluded {
-					ids[*marketHashName] = id
-				}
-			}
-		}
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, nil, err
 	}
 
-	return ids, nil
+	return ids, metadata, nil
 }
 
-func getSteamMarketIDs(marketplace string) (map[string]int, error) {
+func getSteamMarketIDs(ctx context.Context, marketplace string, defIndexes map[string]int) (map[string]int, error) {
 	url := ericZhuAPIBaseURL + marketplace + counterStrikeJSON
 
 	var data map[string]struct {
@@ -726,10 +727,14 @@ func getSteamMarketIDs(marketplace string) (map[string]int, error) {
 		}
 	}
 
+	if err := checkMinItems(url, len(ids)); err != nil {
+		return nil, err
+	}
+
 	return ids, nil
 }
 
-func getChineseMarketIDs(marketplace string) (map[string]int, error) {
+func getChineseMarketIDs(ctx context.Context, marketplace string, defIndexes map[string]int) (map[string]int, error) {
 	url := ericZhuAPIBaseURL + marketplace + counterStrikeJSON
 
 	var data map[string]int
@@ -741,43 +746,86 @@ func getChineseMarketIDs(marketplace string) (map[string]int, error) {
 		if id == -1 {
 			delete(data, name)
 		}
-		if _, exists := defIndexes[name]; exists {
-			delete(data, name)
-		}
+	}
+
+	data = withoutWeapons(data, defIndexes)
+
+	if err := checkMinItems(url, len(data)); err != nil {
+		return nil, err
 	}
 
 	return data, nil
 }
 
-func getModestSerhatIDs(marketplace string) (map[string]int, map[string]int, map[string]map[string]int, map[string]map[string]int, map[string]map[string]int, map[string]int, map[string]map[string][]int, error) {
+// withoutWeapons returns a copy of ids without the bare weapon names of the
+// def indexes.
+func withoutWeapons(ids map[string]int, defIndexes map[string]int) map[string]int {
+	filtered := make(map[string]int, len(ids))
+	for name, id := range ids {
+		if _, isWeapon := defIndexes[name]; !isWeapon {
+			filtered[name] = id
+		}
+	}
+	return filtered
+}
+
+// ModestSerhatIDs holds every dataset taken from the ModestSerhat mapping.
+// Buff163GoodsIDs and YoupinIDs overlap with the EricZhu market IDs and are
+// merged with them by source precedence.
+type ModestSerhatIDs struct {
+	BuffMarketIDs            map[string]int
+	Buff163GoodsIDs          map[string]int
+	YoupinIDs                map[string]int
+	Buff163StickerIDs        map[string]int
+	Buff163PaintseedGroupIDs map[string]map[string]int
+	Buff163PhaseIDs          map[string]map[string]int
+	Buff163TagIDs            map[string]map[string]int
+	Buff163PatchIDs          map[string]int
+	Buff163Patterns          map[string]map[string][]int
+}
+
+func getModestSerhatIDs(ctx context.Context, marketplace string) (*ModestSerhatIDs, error) {
 	url := modestSerhatAPIBaseURL + marketplace
 
 	var data ModestSerhatResponse
 	if err := getRequest(ctx, url, &data); err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, fmt.Errorf("Failed to fetch buff market ids. %w", err)
+		return nil, fmt.Errorf("Failed to fetch buff market ids. %w", err)
 	}
 
-	buffMarketIDs := make(map[string]int, len(data.Items))
-	buff163StickerIDs := make(map[string]int, len(data.Items))
-	buff163PaintseedGroupIDs := make(map[string]map[string]int)
-	buff163PhaseIDs := make(map[string]map[string]int)
-	buff163TagIDs := make(map[string]map[string]int)
-	buff163PatchIDs := make(map[string]int, len(data.Items))
+	ids := &ModestSerhatIDs{
+		BuffMarketIDs:            make(map[string]int, len(data.Items)),
+		Buff163GoodsIDs:          make(map[string]int, len(data.Items)),
+		YoupinIDs:                make(map[string]int, len(data.Items)),
+		Buff163StickerIDs:        make(map[string]int, len(data.Items)),
+		Buff163PaintseedGroupIDs: make(map[string]map[string]int),
+		Buff163PhaseIDs:          make(map[string]map[string]int),
+		Buff163TagIDs:            make(map[string]map[string]int),
+		Buff163PatchIDs:          make(map[string]int, len(data.Items)),
+		Buff163Patterns:          data.Patterns,
+	}
 
 	for name, item := range data.Items {
 		if id := item.BuffMarketGoodsID; id != nil {
-			buffMarketIDs[name] = *id
+			ids.BuffMarketIDs[name] = *id
+		}
+
+		if id := item.Buff163GoodsID; id != nil {
+			ids.Buff163GoodsIDs[name] = *id
+		}
+
+		if id := item.YoupinID; id != nil {
+			ids.YoupinIDs[name] = *id
 		}
 
 		if id := item.Buff163StickerID; id != nil {
-			buff163StickerIDs[name] = *id
+			ids.Buff163StickerIDs[name] = *id
 		}
 
 		if groups := item.Buff163PaintSeedGroupIDs; groups != nil {
-			m := buff163PaintseedGroupIDs[name]
+			m := ids.Buff163PaintseedGroupIDs[name]
 			if m == nil {
 				m = make(map[string]int, len(*groups))
-				buff163PaintseedGroupIDs[name] = m
+				ids.Buff163PaintseedGroupIDs[name] = m
 			}
 			for group, id := range *groups {
 				if id != nil {
@@ -787,10 +835,10 @@ func getModestSerhatIDs(marketplace string) (map[string]int, map[string]int, map
 		}
 
 		if phases := item.Buff163PhaseIDs; phases != nil {
-			m := buff163PhaseIDs[name]
+			m := ids.Buff163PhaseIDs[name]
 			if m == nil {
 				m = make(map[string]int, len(*phases))
-				buff163PhaseIDs[name] = m
+				ids.Buff163PhaseIDs[name] = m
 			}
 			for phase, id := range *phases {
 				if id != nil {
@@ -800,10 +848,10 @@ func getModestSerhatIDs(marketplace string) (map[string]int, map[string]int, map
 		}
 
 		if tags := item.Buff163TagIDs; tags != nil {
-			m := buff163TagIDs[name]
+			m := ids.Buff163TagIDs[name]
 			if m == nil {
 				m = make(map[string]int, len(*tags))
-				buff163TagIDs[name] = m
+				ids.Buff163TagIDs[name] = m
 			}
 			for tag, id := range *tags {
 				if id != nil {
@@ -813,18 +861,113 @@ func getModestSerhatIDs(marketplace string) (map[string]int, map[string]int, map
 		}
 
 		if id := item.Buff163PatchID; id != nil {
-			buff163PatchIDs[name] = *id
+			ids.Buff163PatchIDs[name] = *id
+		}
+	}
+
+	if err := checkMinItems(url, len(ids.BuffMarketIDs)); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+type stagedFile struct {
+	tempPath string
+	filePath string
+}
+
+type outputSet struct {
+	mu     sync.Mutex
+	staged []stagedFile
+	errs   []error
+}
+
+func (o *outputSet) stage(tempPath, filePath string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.staged = append(o.staged, stagedFile{tempPath: tempPath, filePath: filePath})
+}
+
+func (o *outputSet) fail(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.errs = append(o.errs, err)
+}
+
+func (o *outputSet) discard() {
+	for _, file := range o.staged {
+		os.Remove(file.tempPath)
+	}
+	o.staged = nil
+}
+
+// commit replaces the output files with the staged ones, unless a file
+// failed to write or ctx was cancelled, in which case nothing is replaced.
+// The files are renamed one by one, so a rename that fails partway through
+// leaves the files before it already replaced and the rest untouched.
+func (o *outputSet) commit(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.errs) > 0 {
+		o.discard()
+		return errors.Join(o.errs...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		o.discard()
+		return fmt.Errorf("Run cancelled, no output was written. %w", context.Cause(ctx))
+	}
+
+	dirs := make(map[string]struct{})
+	for i, file := range o.staged {
+		if err := os.Rename(file.tempPath, file.filePath); err != nil {
+			o.staged = o.staged[i:]
+			o.discard()
+			return fmt.Errorf("Failed to replace file %s: %w", file.filePath, err)
 		}
+		dirs[filepath.Dir(file.filePath)] = struct{}{}
 	}
+	o.staged = nil
 
-	return buffMarketIDs, buff163StickerIDs, buff163PaintseedGroupIDs, buff163PhaseIDs, buff163TagIDs, buff163PatchIDs, data.Patterns, nil
+	for dir := range dirs {
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func saveData[T any](data map[string]T, filePath string, isPretty bool) {
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("Failed to open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync directory %s: %w", dir, err)
+	}
+
+	return nil
+}
+
+func saveData[T any](output *outputSet, data map[string]T, filePath string, isPretty bool) error {
 	if data == nil {
-		return
+		return nil
 	}
 
+	return writeFile(output, filePath, func(w io.Writer) error {
+		if err := encodeData(w, data, isPretty); err != nil {
+			return fmt.Errorf("Failed to encode data to JSON for %s: %w", filePath, err)
+		}
+		return nil
+	})
+}
+
+func encodeData[T any](w io.Writer, data map[string]T, isPretty bool) error {
 	sortedKeys := make([]string, 0, len(data))
 	for key := range data {
 		sortedKeys = append(sortedKeys, key)
@@ -836,59 +979,216 @@ func saveData[T any](data map[string]T, filePath string, isPretty bool) {
 		sortedDataMap[key] = data[key]
 	}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		fmt.Printf("Failed to create file %s: %s\n", filePath, err)
-		return
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
+	encoder := json.NewEncoder(w)
 	encoder.SetEscapeHTML(false)
 	if isPretty {
 		encoder.SetIndent("", "    ")
 	}
 
-	err = encoder.Encode(sortedDataMap)
+	return encoder.Encode(sortedDataMap)
+}
+
+func writeFile(output *outputSet, filePath string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
 	if err != nil {
-		fmt.Printf("Failed to encode data to JSON: %s\n", err)
+		return fmt.Errorf("Failed to create temporary file for %s: %w", filePath, err)
+	}
+	tempPath := file.Name()
+
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to set permissions on file %s: %w", tempPath, err)
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to sync file %s: %w", tempPath, err)
 	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to close file %s: %w", tempPath, err)
+	}
+
+	output.stage(tempPath, filePath)
+
+	return nil
 }
 
-func saveDataAsync[T any](wg *sync.WaitGroup, data map[string]T, basePath string) {
+func saveDataAsync[T any](wg *sync.WaitGroup, output *outputSet, data map[string]T, basePath string) {
 	miniPath := "./mini/" + basePath
 	prettyPath := "./pretty/" + basePath
 
-	wg.Add(2)
+	tablePath := strings.TrimSuffix(basePath, ".json")
+	csvPath := "./csv/" + tablePath + ".csv"
+	tsvPath := "./tsv/" + tablePath + ".tsv"
+
+	wg.Add(4 + len(compressors))
 	go func() {
 		defer wg.Done()
-		saveData(data, miniPath, false)
+		if err := saveData(output, data, miniPath, false); err != nil {
+			output.fail(err)
+		}
 	}()
+	for _, compressor := range compressors {
+		go func() {
+			defer wg.Done()
+			if err := saveCompressed(output, data, miniPath+compressor.extension, compressor.newWriter); err != nil {
+				output.fail(err)
+			}
+		}()
+	}
 	go func() {
 		defer wg.Done()
-		saveData(data, prettyPath, true)
+		if err := saveData(output, data, prettyPath, true); err != nil {
+			output.fail(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := saveCSV(output, data, csvPath); err != nil {
+			output.fail(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := saveTSV(output, data, tsvPath); err != nil {
+			output.fail(err)
+		}
 	}()
 }
 
 func main() {
-	dirs := []string{
-		"./mini/buff163_grouped_ids",
-		"./mini/steam_grouped_ids",
-		"./mini/steam_indexes",
-		"./mini/market_ids",
-		"./pretty/buff163_grouped_ids",
-		"./pretty/steam_grouped_ids",
-		"./pretty/steam_indexes",
-		"./pretty/market_ids",
+	command := "generate"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
 	}
 
+	switch command {
+
+	case "generate":
+		if err := generate(os.Args[min(2, len(os.Args)):]); err != nil {
+			fmt.Println("Generate failed. ", err)
+			os.Exit(1)
+		}
+
+	case "serve":
+		if err := runServe(os.Args[2:]); err != nil {
+			fmt.Println("Server failed. ", err)
+			os.Exit(1)
+		}
+
+	case "search":
+		if err := runSearch(os.Args[2:]); err != nil {
+			fmt.Println("Search failed. ", err)
+			os.Exit(1)
+		}
+
+	case "pattern":
+		if err := runPattern(os.Args[2:]); err != nil {
+			fmt.Println("Pattern lookup failed. ", err)
+			os.Exit(1)
+		}
+
+	case "paintseed":
+		if err := runPaintseed(os.Args[2:]); err != nil {
+			fmt.Println("Paintseed lookup failed. ", err)
+			os.Exit(1)
+		}
+
+	case "inventory":
+		if err := runInventory(os.Args[2:]); err != nil {
+			fmt.Println("Inventory import failed. ", err)
+			os.Exit(1)
+		}
+
+	case "lock":
+		if err := runLock(os.Args[2:]); err != nil {
+			fmt.Println("Lock failed. ", err)
+			os.Exit(1)
+		}
+
+	case "url":
+		if err := runURL(os.Args[2:]); err != nil {
+			fmt.Println("URL lookup failed. ", err)
+			os.Exit(1)
+		}
+
+	default:
+		fmt.Printf("Unknown command %s\n", command)
+		os.Exit(2)
+	}
+}
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	exclusionsPath := flags.String("exclusions", "exclusions.json", "path to the exclusion rules")
+	overridesPath := flags.String("overrides", "overrides.json", "path to the manual overrides")
+	sourcesPath := flags.String("sources", "sources.json", "path to the source precedence")
+	lockPath := flags.String("lock", "sources.lock.json", "path to the lock file pinning the upstreams")
+	timeout := flags.Duration("timeout", 5*time.Minute, "deadline for the whole run")
+	sourceTimeout := flags.Duration("source-timeout", time.Minute, "deadline for fetching a single source")
+	flags.Parse(args)
+
+	// SIGINT and SIGTERM cancel the run instead of killing the process, so
+	// it can never stop halfway through replacing the output files.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	exclusions, err := loadExclusions(*exclusionsPath)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := loadOverrides(*overridesPath)
+	if err != nil {
+		return err
+	}
+
+	sources, err := loadSources(*sourcesPath)
+	if err != nil {
+		return err
+	}
+
+	defaultHttpClient.Transport = newLimitedTransport(defaultHttpClient.Transport, sources.Hosts)
+
+	lock, err := loadLock(*lockPath)
+	if err != nil {
+		return err
+	}
+
+	provenance := lock.pin()
+
+	formats := []string{"mini", "pretty", "csv", "tsv"}
+	categories := []string{"buff163_grouped_ids", "steam_grouped_ids", "steam_indexes", "market_ids"}
+
+	dirs := make([]string, 0, len(formats)*len(categories))
+	for _, format := range formats {
+		for _, category := range categories {
+			dirs = append(dirs, "./"+format+"/"+category)
+		}
+	}
+
+	dirs = append(dirs, "./sqlite", "./binary", "./reports")
+
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
-			fmt.Printf("Failed to create directory %s: %v\n", dir, err)
-			return
+			return fmt.Errorf("Failed to create directory %s: %w", dir, err)
 		}
 	}
 
+	var defIndexes map[string]int
 	var paintIndexes map[string]int
 	var steamAgentIDs map[string]int
 	var steamCollectibleIDs map[string]int
@@ -896,214 +1196,318 @@ func main() {
 	var steamGraffitiIDs map[string]string
 	var steamHighlightIDs map[string]string
 	var steamKeychainIDs map[string]int
-	var steamKeyIDs map[string]any
+	var steamKeys map[string]KeyRecord
 	var steamMusicKitIDs map[string]int
 	var steamPatchIDs map[string]int
 	var steamStickerIDs map[string]int
-	var buffMarketIDs map[string]int
-	var buff163StickerIDs map[string]int
-	var buff163PaintseedGroupIDs map[string]map[string]int
-	var buff163PhaseIDs map[string]map[string]int
-	var buff163TagIDs map[string]map[string]int
-	var buff163PatchIDs map[string]int
-	var buff163Patterns map[string]map[string][]int
-
-	errs := make(chan error, 12)
-	var wg sync.WaitGroup
-	wg.Add(12)
+	var steamStickerMetadata map[string]StickerMetadata
+	var steamMarketIDs map[string]int
+	modestSerhat := &ModestSerhatIDs{}
+	var modestSerhatBuff163IDs map[string]int
+	var modestSerhatYoupinIDs map[string]int
 
-	go func() {
-		defer wg.Done()
-		var err error
-		defIndexes, paintIndexes, err = getSteamIndexes("skins.json")
-		if err != nil {
-			errs <- err
-		}
-	}()
+	chineseMarketplaces := []string{"buff", "c5", "uuyp", "igxe"}
+	chineseMarketIDs := make(map[string]map[string]int)
+	var mu sync.Mutex
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamAgentIDs, err = getSteamAgentIDs("agents.json")
-		if err != nil {
-			errs <- err
-			return
-		}
-	}()
+	stages := []stage{
+		{name: "skins", run: func(ctx context.Context) (err error) {
+			defIndexes, paintIndexes, err = getSteamIndexes(ctx, "skins.json")
+			return err
+		}},
+		{name: "agents", run: func(ctx context.Context) (err error) {
+			steamAgentIDs, err = getSteamAgentIDs(ctx, "agents.json")
+			return err
+		}},
+		{name: "collectibles", run: func(ctx context.Context) (err error) {
+			steamCollectibleIDs, err = getSteamCollectibleIDs(ctx, "collectibles.json")
+			return err
+		}},
+		{name: "crates", run: func(ctx context.Context) (err error) {
+			steamCrateIDs, err = getSteamCrateIDs(ctx, "crates.json")
+			return err
+		}},
+		{name: "graffiti", run: func(ctx context.Context) (err error) {
+			steamGraffitiIDs, err = getSteamGraffitiIDs(ctx, "graffiti.json")
+			return err
+		}},
+		{name: "highlights", run: func(ctx context.Context) (err error) {
+			steamHighlightIDs, err = getSteamHighlightIDs(ctx, "highlights.json")
+			return err
+		}},
+		{name: "keychains", run: func(ctx context.Context) (err error) {
+			steamKeychainIDs, err = getSteamKeychainIDs(ctx, "keychains.json")
+			return err
+		}},
+		{name: "keys", run: func(ctx context.Context) (err error) {
+			steamKeys, err = getSteamKeyIDs(ctx, "keys.json")
+			return err
+		}},
+		{name: "music_kits", run: func(ctx context.Context) (err error) {
+			steamMusicKitIDs, err = getSteamMusicKitIDs(ctx, "music_kits.json")
+			return err
+		}},
+		{name: "patches", run: func(ctx context.Context) (err error) {
+			steamPatchIDs, err = getSteamPatchIDs(ctx, "patches.json")
+			return err
+		}},
+		{name: "stickers", run: func(ctx context.Context) (err error) {
+			steamStickerIDs, steamStickerMetadata, err = getSteamStickerIDs(ctx, "stickers.json")
+			return err
+		}},
+		{name: "modestserhat", run: func(ctx context.Context) error {
+			ids, err := getModestSerhatIDs(ctx, "cs2_marketplaceids.json")
+			if err != nil {
+				return err
+			}
+			modestSerhat = ids
+			return nil
+		}},
+		{name: "ericzhu_steam", deps: []string{"skins"}, run: func(ctx context.Context) (err error) {
+			steamMarketIDs, err = getSteamMarketIDs(ctx, "steam", defIndexes)
+			return err
+		}},
+		{name: "modestserhat_market", deps: []string{"skins", "modestserhat"}, run: func(ctx context.Context) error {
+			modestSerhatBuff163IDs = withoutWeapons(modestSerhat.Buff163GoodsIDs, defIndexes)
+			modestSerhatYoupinIDs = withoutWeapons(modestSerhat.YoupinIDs, defIndexes)
+			return nil
+		}},
+	}
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamCollectibleIDs, err = getSteamCollectibleIDs("collectibles.json")
-		if err != nil {
-			errs <- err
-			return
-		}
-	}()
+	for _, marketplace := range chineseMarketplaces {
+		stages = append(stages, stage{name: "ericzhu_" + marketplace, deps: []string{"skins"}, run: func(ctx context.Context) error {
+			ids, err := getChineseMarketIDs(ctx, marketplace, defIndexes)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			chineseMarketIDs[marketplace] = ids
+			mu.Unlock()
+			return nil
+		}})
+	}
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamCrateIDs, err = getSteamCrateIDs("crates.json")
-		if err != nil {
-			errs <- err
-			return
-		}
-	}()
+	stageErrs, err := runStages(ctx, stages, *sourceTimeout)
+	if err != nil {
+		return err
+	}
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamGraffitiIDs, err = getSteamGraffitiIDs("graffiti.json")
-		if err != nil {
-			errs <- err
-			return
-		}
-	}()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("Run cancelled, no output was written. %w", context.Cause(ctx))
+	}
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamHighlightIDs, err = getSteamHighlightIDs("highlights.json")
-		if err != nil {
-			errs <- err
-			return
-		}
-	}()
+	for _, name := range sortedKeys(stageErrs) {
+		err := stageErrs[name]
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamKeychainIDs, err = getSteamKeychainIDs("keychains.json")
-		if err != nil {
-			errs <- err
-			return
+		kind := "error"
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) {
+			kind = fetchErr.Kind.String() + " error"
+		} else if errors.Is(err, errStageSkipped) {
+			kind = "skipped"
 		}
-	}()
 
-	go func() {
-		defer wg.Done()
-		var err error
-		steamKeyIDs, err = getSteamKeyIDs("keys.json")
-		if err != nil {
-			errs <- err
-			return
+		fmt.Printf("Error during API fetch of %s (%s). %v\n", name, kind, err)
+	}
+
+	sourceConflicts := make(map[string]map[string]SourceConflict)
+
+	buff163IDs, buff163Conflicts, err := sources.resolve("buff163", map[string]map[string]int{
+		"ericzhu":      chineseMarketIDs["buff"],
+		"modestserhat": modestSerhatBuff163IDs,
+	})
+	if err != nil {
+		return err
+	}
+	if buff163IDs == nil {
+		fmt.Printf("Keeping the previous market_ids/buff163.json, %s was not fetched\n", sources.Precedence["buff163"][0])
+	}
+	sourceConflicts["buff163"] = buff163Conflicts
+
+	youpinIDs, youpinConflicts, err := sources.resolve("youpin898", map[string]map[string]int{
+		"ericzhu":      chineseMarketIDs["uuyp"],
+		"modestserhat": modestSerhatYoupinIDs,
+	})
+	if err != nil {
+		return err
+	}
+	if youpinIDs == nil {
+		fmt.Printf("Keeping the previous market_ids/youpin898.json, %s was not fetched\n", sources.Precedence["youpin898"][0])
+	}
+	sourceConflicts["youpin898"] = youpinConflicts
+
+	for _, marketplace := range sortedKeys(sourceConflicts) {
+		if conflicts := sourceConflicts[marketplace]; len(conflicts) > 0 {
+			fmt.Printf("Sources disagree on %d %s IDs, see reports/source_conflicts.json\n", len(conflicts), marketplace)
 		}
-	}()
+	}
+
+	catalog := &Catalog{
+		DefIndexes:   defIndexes,
+		PaintIndexes: paintIndexes,
+
+		SteamAgentIDs:       steamAgentIDs,
+		SteamCollectibleIDs: steamCollectibleIDs,
+		SteamCrateIDs:       steamCrateIDs,
+		SteamGraffitiIDs:    steamGraffitiIDs,
+		SteamHighlightIDs:   steamHighlightIDs,
+		SteamKeychainIDs:    steamKeychainIDs,
+		SteamKeys:           steamKeys,
+		SteamMusicKitIDs:    steamMusicKitIDs,
+		SteamPatchIDs:       steamPatchIDs,
+		SteamStickerIDs:     steamStickerIDs,
+		StickerMetadata:     steamStickerMetadata,
+
+		SteamMarketIDs: steamMarketIDs,
+		Buff163IDs:     buff163IDs,
+		BuffMarketIDs:  modestSerhat.BuffMarketIDs,
+		C5GameIDs:      chineseMarketIDs["c5"],
+		YoupinIDs:      youpinIDs,
+		IGXEIDs:        chineseMarketIDs["igxe"],
+
+		Buff163StickerIDs:        modestSerhat.Buff163StickerIDs,
+		Buff163PaintseedGroupIDs: modestSerhat.Buff163PaintseedGroupIDs,
+		Buff163PhaseIDs:          modestSerhat.Buff163PhaseIDs,
+		Buff163TagIDs:            modestSerhat.Buff163TagIDs,
+		Buff163PatchIDs:          modestSerhat.Buff163PatchIDs,
+		Buff163Patterns:          modestSerhat.Buff163Patterns,
+	}
 
+	// Exclusions and overrides of fetched datasets run before anything is
+	// derived, so derived datasets never contain excluded items and pick up
+	// overridden values. Overrides of derived datasets run once they are
+	// built.
+	exclusionReport := exclusions.apply(catalog)
+	printExclusionReport(exclusionReport)
+
+	now := time.Now()
+	overrideWarnings, err := overrides.apply(catalog, now, false)
+	if err != nil {
+		return err
+	}
+
+	catalog.SteamKeyIDs = legacyKeyIDs(catalog.SteamKeys)
+	catalog.SteamGraffiti = buildGraffiti(catalog.SteamGraffitiIDs)
+	catalog.GraffitiTints = maps.Clone(graffitiTints)
+	catalog.PatternTiers = buildPatternTiers(catalog.Buff163Patterns)
+	catalog.Phases = buildPhases(catalog.PaintIndexes, catalog.Buff163PhaseIDs)
+	catalog.CrateKeys = buildCrateKeys(catalog)
+
+	derivedWarnings, err := overrides.apply(catalog, now, true)
+	if err != nil {
+		return err
+	}
+	for _, warning := range append(overrideWarnings, derivedWarnings...) {
+		fmt.Println(warning)
+	}
+
+	var wg sync.WaitGroup
+	var output outputSet
+
+	saveDataAsync(&wg, &output, catalog.DefIndexes, "steam_indexes/def_indexes.json")
+	saveDataAsync(&wg, &output, catalog.PaintIndexes, "steam_indexes/paint_indexes.json")
+	saveDataAsync(&wg, &output, catalog.Phases, "steam_indexes/phases.json")
+
+	saveDataAsync(&wg, &output, catalog.SteamAgentIDs, "steam_grouped_ids/agents.json")
+	saveDataAsync(&wg, &output, catalog.SteamCollectibleIDs, "steam_grouped_ids/collectibles.json")
+	saveDataAsync(&wg, &output, catalog.SteamCrateIDs, "steam_grouped_ids/crates.json")
+	saveDataAsync(&wg, &output, catalog.CrateKeys, "steam_grouped_ids/crate_keys.json")
+	saveDataAsync(&wg, &output, catalog.SteamGraffitiIDs, "steam_grouped_ids/graffiti.json")
+	saveDataAsync(&wg, &output, catalog.SteamGraffiti, "steam_grouped_ids/graffiti_kits.json")
+	saveDataAsync(&wg, &output, catalog.GraffitiTints, "steam_grouped_ids/graffiti_tints.json")
+	saveDataAsync(&wg, &output, catalog.SteamHighlightIDs, "steam_grouped_ids/highlights.json")
+	saveDataAsync(&wg, &output, catalog.SteamKeychainIDs, "steam_grouped_ids/keychains.json")
+	saveDataAsync(&wg, &output, catalog.SteamKeyIDs, "steam_grouped_ids/keys.json")
+	saveDataAsync(&wg, &output, catalog.SteamKeys, "steam_grouped_ids/keys_v2.json")
+	saveDataAsync(&wg, &output, catalog.SteamMusicKitIDs, "steam_grouped_ids/music_kits.json")
+	saveDataAsync(&wg, &output, catalog.SteamPatchIDs, "steam_grouped_ids/patches.json")
+	saveDataAsync(&wg, &output, catalog.SteamStickerIDs, "steam_grouped_ids/stickers.json")
+	saveDataAsync(&wg, &output, catalog.StickerMetadata, "steam_grouped_ids/sticker_metadata.json")
+
+	saveDataAsync(&wg, &output, catalog.SteamMarketIDs, "market_ids/steam.json")
+	saveDataAsync(&wg, &output, catalog.Buff163IDs, "market_ids/buff163.json")
+	saveDataAsync(&wg, &output, catalog.C5GameIDs, "market_ids/c5game.json")
+	saveDataAsync(&wg, &output, catalog.YoupinIDs, "market_ids/youpin898.json")
+	saveDataAsync(&wg, &output, catalog.IGXEIDs, "market_ids/igxe.json")
+	saveDataAsync(&wg, &output, catalog.BuffMarketIDs, "market_ids/buff_market.json")
+
+	saveDataAsync(&wg, &output, catalog.Buff163StickerIDs, "buff163_grouped_ids/stickers.json")
+	saveDataAsync(&wg, &output, catalog.Buff163PaintseedGroupIDs, "buff163_grouped_ids/paintseed_group_ids.json")
+	saveDataAsync(&wg, &output, catalog.Buff163PhaseIDs, "buff163_grouped_ids/phases.json")
+	saveDataAsync(&wg, &output, catalog.Buff163TagIDs, "buff163_grouped_ids/tags.json")
+	saveDataAsync(&wg, &output, catalog.Buff163PatchIDs, "buff163_grouped_ids/patches.json")
+	saveDataAsync(&wg, &output, catalog.Buff163Patterns, "buff163_grouped_ids/patterns.json")
+	saveDataAsync(&wg, &output, catalog.PatternTiers, "buff163_grouped_ids/pattern_tiers.json")
+
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		var err error
-		steamMusicKitIDs, err = getSteamMusicKitIDs("music_kits.json")
-		if err != nil {
-			errs <- err
-			return
+		if err := saveData(&output, exclusionReport, "./reports/exclusions.json", true); err != nil {
+			output.fail(err)
 		}
 	}()
 
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		var err error
-		steamPatchIDs, err = getSteamPatchIDs("patches.json")
-		if err != nil {
-			errs <- err
-			return
+		if err := saveData(&output, sourceConflicts, "./reports/source_conflicts.json", true); err != nil {
+			output.fail(err)
 		}
 	}()
 
+	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		var err error
-		steamStickerIDs, err = getSteamStickerIDs("stickers.json")
-		if err != nil {
-			errs <- err
-			return
+		if err := saveData(&output, provenance, "./mini/provenance.json", false); err != nil {
+			output.fail(err)
 		}
 	}()
-
 	go func() {
 		defer wg.Done()
-		var err error
-		buffMarketIDs, buff163StickerIDs, buff163PaintseedGroupIDs, buff163PhaseIDs, buff163TagIDs, buff163PatchIDs, buff163Patterns, err = getModestSerhatIDs("cs2_marketplaceids.json")
-		if err != nil {
-			errs <- err
-			return
+		if err := saveData(&output, provenance, "./pretty/provenance.json", true); err != nil {
+			output.fail(err)
 		}
 	}()
 
-	wg.Wait()
-	close(errs)
-
-	for err := range errs {
-		fmt.Println("Error during API fetch. ", err)
+	unseededGroups := catalog.UnseededPaintseedGroups()
+	for _, name := range sortedKeys(unseededGroups) {
+		fmt.Printf("BUFF.163 paintseed groups without a seed list for %s: %s\n", name, strings.Join(unseededGroups[name], ", "))
 	}
 
-	var steamMarketIDs map[string]int
-	chineseMarketplaces := []string{"buff", "c5", "uuyp", "igxe"}
-	chineseMarketIDs := make(map[string]map[string]int)
-
-	var mu sync.Mutex
-	errs = make(chan error, 5)
-	wg.Add(5)
+	marketIDs := &marketids.MarketIDs{
+		Steam:      catalog.SteamMarketIDs,
+		Buff163:    catalog.Buff163IDs,
+		BuffMarket: catalog.BuffMarketIDs,
+		C5Game:     catalog.C5GameIDs,
+		Youpin898:  catalog.YoupinIDs,
+		IGXE:       catalog.IGXEIDs,
+	}
 
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		var err error
-		steamMarketIDs, err = getSteamMarketIDs("steam")
-		if err != nil {
-			errs <- err
-			return
+		if err := saveMarketIDsBinary(&output, marketIDs, "./binary/market_ids.pb"); err != nil {
+			output.fail(err)
 		}
 	}()
 
-	for _, marketplace := range chineseMarketplaces {
-		go func(marketplace string) {
+	if missing := catalog.missing(); len(missing) > 0 {
+		fmt.Println("Skipping SQLite database, missing datasets: ", strings.Join(missing, ", "))
+	} else {
+		wg.Add(1)
+		go func() {
 			defer wg.Done()
-			ids, err := getChineseMarketIDs(marketplace)
-			if err != nil {
-				errs <- err
-				return
+			if err := saveSQLite(&output, catalog, "./sqlite/catalog.sqlite"); err != nil {
+				output.fail(err)
 			}
-			mu.Lock()
-			chineseMarketIDs[marketplace] = ids
-			mu.Unlock()
-		}(marketplace)
+		}()
 	}
 
 	wg.Wait()
-	close(errs)
-
-	for err := range errs {
-		fmt.Println("Error during API fetch. ", err)
-	}
-
-	saveDataAsync(&wg, defIndexes, "steam_indexes/def_indexes.json")
-	saveDataAsync(&wg, paintIndexes, "steam_indexes/paint_indexes.json")
-
-	saveDataAsync(&wg, steamAgentIDs, "steam_grouped_ids/agents.json")
-	saveDataAsync(&wg, steamCollectibleIDs, "steam_grouped_ids/collectibles.json")
-	saveDataAsync(&wg, steamCrateIDs, "steam_grouped_ids/crates.json")
-	saveDataAsync(&wg, steamGraffitiIDs, "steam_grouped_ids/graffiti.json")
-	saveDataAsync(&wg, steamHighlightIDs, "steam_grouped_ids/highlights.json")
-	saveDataAsync(&wg, steamKeychainIDs, "steam_grouped_ids/keychains.json")
-	saveDataAsync(&wg, steamKeyIDs, "steam_grouped_ids/keys.json")
-	saveDataAsync(&wg, steamMusicKitIDs, "steam_grouped_ids/music_kits.json")
-	saveDataAsync(&wg, steamPatchIDs, "steam_grouped_ids/patches.json")
-	saveDataAsync(&wg, steamStickerIDs, "steam_grouped_ids/stickers.json")
-
-	saveDataAsync(&wg, steamMarketIDs, "market_ids/steam.json")
-	saveDataAsync(&wg, chineseMarketIDs["buff"], "market_ids/buff163.json")
-	saveDataAsync(&wg, chineseMarketIDs["c5"], "market_ids/c5game.json")
-	saveDataAsync(&wg, chineseMarketIDs["uuyp"], "market_ids/youpin898.json")
-	saveDataAsync(&wg, chineseMarketIDs["igxe"], "market_ids/igxe.json")
-	saveDataAsync(&wg, buffMarketIDs, "market_ids/buff_market.json")
-
-	saveDataAsync(&wg, buff163StickerIDs, "buff163_grouped_ids/stickers.json")
-	saveDataAsync(&wg, buff163PaintseedGroupIDs, "buff163_grouped_ids/paintseed_group_ids.json")
-	saveDataAsync(&wg, buff163PhaseIDs, "buff163_grouped_ids/phases.json")
-	saveDataAsync(&wg, buff163TagIDs, "buff163_grouped_ids/tags.json")
-	saveDataAsync(&wg, buff163PatchIDs, "buff163_grouped_ids/patches.json")
-	saveDataAsync(&wg, buff163Patterns, "buff163_grouped_ids/patterns.json")
 
-	wg.Wait()
+	if err := output.commit(ctx); err != nil {
+		return fmt.Errorf("Failed to write output files. %w", err)
+	}
+
+	return nil
 }
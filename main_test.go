@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeOutputs creates the existing output files of a test.
+func writeOutputs(t *testing.T, files map[string]string) {
+	t.Helper()
+	for filePath, content := range files {
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkOutputs(t *testing.T, files map[string]string) {
+	t.Helper()
+	for filePath, want := range files {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(filePath), data, want)
+		}
+	}
+}
+
+func checkNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	temps, err := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(temps) > 0 {
+		t.Errorf("temporary files left behind: %q", temps)
+	}
+}
+
+func TestOutputSetCommit(t *testing.T) {
+	dir := t.TempDir()
+	steamPath := filepath.Join(dir, "steam.json")
+	buffPath := filepath.Join(dir, "buff163.json")
+	newPath := filepath.Join(dir, "igxe.json")
+	writeOutputs(t, map[string]string{steamPath: "old steam", buffPath: "old buff"})
+
+	var output outputSet
+	for _, file := range []struct {
+		path string
+		data map[string]int
+	}{
+		{steamPath, map[string]int{"b": 2, "a": 1}},
+		{buffPath, map[string]int{"c": 3}},
+		{newPath, map[string]int{}},
+	} {
+		if err := saveData(&output, file.data, file.path, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing is replaced before commit.
+	checkOutputs(t, map[string]string{steamPath: "old steam", buffPath: "old buff"})
+
+	if err := output.commit(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutputs(t, map[string]string{
+		steamPath: `{"a":1,"b":2}` + "\n",
+		buffPath:  `{"c":3}` + "\n",
+		newPath:   "{}\n",
+	})
+	checkNoTempFiles(t, dir)
+
+	info, err := os.Stat(newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("%s has mode %s, want 0644", newPath, info.Mode().Perm())
+	}
+}
+
+func TestOutputSetFailedEncode(t *testing.T) {
+	dir := t.TempDir()
+	steamPath := filepath.Join(dir, "steam.json")
+	brokenPath := filepath.Join(dir, "broken.json")
+	existing := map[string]string{steamPath: "old steam", brokenPath: "old broken"}
+	writeOutputs(t, existing)
+
+	var output outputSet
+	if err := saveData(&output, map[string]int{"a": 1}, steamPath, false); err != nil {
+		t.Fatal(err)
+	}
+	err := saveData(&output, map[string]any{"a": make(chan int)}, brokenPath, false)
+	if err == nil {
+		t.Fatal("saveData() encoded a channel")
+	}
+	output.fail(err)
+
+	if err := output.commit(context.Background()); err == nil || !strings.Contains(err.Error(), "Failed to encode data to JSON") {
+		t.Fatalf("commit() error = %v, want the encode error", err)
+	}
+
+	checkOutputs(t, existing)
+	checkNoTempFiles(t, dir)
+}
+
+func TestOutputSetCancelled(t *testing.T) {
+	dir := t.TempDir()
+	steamPath := filepath.Join(dir, "steam.json")
+	newPath := filepath.Join(dir, "igxe.json")
+	writeOutputs(t, map[string]string{steamPath: "old steam"})
+
+	var output outputSet
+	for _, filePath := range []string{steamPath, newPath} {
+		if err := saveData(&output, map[string]int{"a": 1}, filePath, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := output.commit(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("commit() error = %v, want the cancellation", err)
+	}
+
+	checkOutputs(t, map[string]string{steamPath: "old steam"})
+	if _, err := os.Stat(newPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s was created by a cancelled run", newPath)
+	}
+	checkNoTempFiles(t, dir)
+}
+
+func TestOutputSetRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	firstPath := filepath.Join(dir, "first.json")
+	// A non-empty directory cannot be replaced by a file.
+	blockedPath := filepath.Join(dir, "blocked.json")
+	if err := os.MkdirAll(filepath.Join(blockedPath, "child"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeOutputs(t, map[string]string{firstPath: "old first"})
+
+	var output outputSet
+	for _, filePath := range []string{firstPath, blockedPath} {
+		err := writeFile(&output, filePath, func(w io.Writer) error {
+			_, err := io.WriteString(w, "new")
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := output.commit(context.Background()); err == nil || !strings.Contains(err.Error(), "Failed to replace file") {
+		t.Fatalf("commit() error = %v, want the rename error", err)
+	}
+
+	// Files renamed before the failure stay replaced.
+	checkOutputs(t, map[string]string{firstPath: "new"})
+	checkNoTempFiles(t, dir)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const overridesVersion = 1
+
+// Override patches a single entry of a fetched dataset. Key selects an entry
+// of a nested map such as the phases or tags of an item, Value is decoded
+// into the element type of the dataset.
+//
+//   - set writes the entry, creating it when upstream does not have it
+//   - replace writes the entry only when upstream has it
+//   - delete removes the entry
+type Override struct {
+	Dataset string          `json:"dataset"`
+	Name    string          `json:"name"`
+	Key     string          `json:"key,omitempty"`
+	Op      string          `json:"op"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Reason  string          `json:"reason"`
+	Expires string          `json:"expires,omitempty"`
+
+	expires time.Time
+}
+
+type OverrideConfig struct {
+	Version   int        `json:"version"`
+	Overrides []Override `json:"overrides"`
+}
+
+func loadOverrides(filePath string) (*OverrideConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read overrides %s: %w", filePath, err)
+	}
+
+	var config OverrideConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Failed to decode overrides %s: %w", filePath, err)
+	}
+
+	if config.Version != overridesVersion {
+		return nil, fmt.Errorf("Unsupported overrides version %d in %s, expected %d", config.Version, filePath, overridesVersion)
+	}
+
+	for i := range config.Overrides {
+		override := &config.Overrides[i]
+
+		switch override.Op {
+		case "set", "replace":
+			if len(override.Value) == 0 {
+				return nil, fmt.Errorf("Override %s has no value", override)
+			}
+		case "delete":
+		default:
+			return nil, fmt.Errorf("Override %s has unknown op %q", override, override.Op)
+		}
+
+		if override.Reason == "" {
+			return nil, fmt.Errorf("Override %s has no reason", override)
+		}
+
+		if override.Expires != "" {
+			override.expires, err = time.Parse(time.DateOnly, override.Expires)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid expiry in override %s: %w", override, err)
+			}
+		}
+	}
+
+	return &config, nil
+}
+
+func (o *Override) String() string {
+	if o.Key != "" {
+		return fmt.Sprintf("%s %s[%s][%s]", o.Op, o.Dataset, o.Name, o.Key)
+	}
+	return fmt.Sprintf("%s %s[%s]", o.Op, o.Dataset, o.Name)
+}
+
+// expired reports whether the override is past the end of its expiry day.
+func (o *Override) expired(now time.Time) bool {
+	return !o.expires.IsZero() && !now.Before(o.expires.AddDate(0, 0, 1))
+}
+
+// derivedDatasets are built from other datasets after fetching. Overrides of
+// them are applied once they are built, overrides of every other dataset
+// before, so derived datasets pick up corrected upstream values.
+var derivedDatasets = map[string]bool{
+	"steam_indexes/phases.json":              true,
+	"steam_grouped_ids/graffiti_kits.json":   true,
+	"steam_grouped_ids/graffiti_tints.json":  true,
+	"steam_grouped_ids/keys.json":            true,
+	"steam_grouped_ids/crate_keys.json":      true,
+	"buff163_grouped_ids/pattern_tiers.json": true,
+}
+
+// apply patches either the derived or the fetched datasets of the catalog
+// and returns a warning for every override that was skipped or is no longer
+// needed.
+func (config *OverrideConfig) apply(catalog *Catalog, now time.Time, derived bool) ([]string, error) {
+	datasets := make(map[string]reflect.Value)
+	for _, file := range catalog.files() {
+		datasets[file.path] = reflect.ValueOf(file.target).Elem()
+	}
+
+	var warnings []string
+
+	for i := range config.Overrides {
+		override := &config.Overrides[i]
+
+		data, exists := datasets[override.Dataset]
+		if !exists {
+			return nil, fmt.Errorf("Override %s targets unknown dataset %s", override, override.Dataset)
+		}
+
+		if derivedDatasets[override.Dataset] != derived {
+			continue
+		}
+
+		if override.expired(now) {
+			warnings = append(warnings, fmt.Sprintf("Override %s expired on %s and was skipped", override, override.Expires))
+			continue
+		}
+
+		if data.IsNil() {
+			warnings = append(warnings, fmt.Sprintf("Override %s was skipped, %s is missing from this run", override, override.Dataset))
+			continue
+		}
+
+		// For nested datasets the override applies to the inner map of the
+		// item, which is created on demand by set.
+		key := reflect.ValueOf(override.Name)
+		target := data
+		if override.Key != "" {
+			if data.Type().Elem().Kind() != reflect.Map {
+				return nil, fmt.Errorf("Override %s has a key but %s is not nested", override, override.Dataset)
+			}
+
+			target = data.MapIndex(key)
+			if !target.IsValid() || target.IsNil() {
+				if override.Op != "set" {
+					warnings = append(warnings, fmt.Sprintf("Override %s is redundant, upstream has no entry", override))
+					continue
+				}
+				target = reflect.MakeMap(data.Type().Elem())
+				data.SetMapIndex(key, target)
+			}
+
+			key = reflect.ValueOf(override.Key)
+		}
+		key, err := convertKey(key.String(), target.Type().Key())
+		if err != nil {
+			return nil, fmt.Errorf("Override %s: %w", override, err)
+		}
+		current := target.MapIndex(key)
+
+		if override.Op == "delete" {
+			if !current.IsValid() {
+				warnings = append(warnings, fmt.Sprintf("Override %s is redundant, upstream has no entry", override))
+				continue
+			}
+			target.SetMapIndex(key, reflect.Value{})
+			continue
+		}
+
+		value := reflect.New(target.Type().Elem())
+		decoder := json.NewDecoder(bytes.NewReader(override.Value))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(value.Interface()); err != nil {
+			return nil, fmt.Errorf("Invalid value in override %s: %w", override, err)
+		}
+
+		if !current.IsValid() && override.Op == "replace" {
+			warnings = append(warnings, fmt.Sprintf("Override %s is redundant, upstream has no entry", override))
+			continue
+		}
+
+		if current.IsValid() && reflect.DeepEqual(current.Interface(), value.Elem().Interface()) {
+			warnings = append(warnings, fmt.Sprintf("Override %s is redundant, upstream already has %s", override, override.Value))
+		}
+
+		target.SetMapIndex(key, value.Elem())
+	}
+
+	return warnings, nil
+}
+
+// convertKey converts an override name or key to the key type of a map, which
+// is a string for every dataset except the paint seeds of pattern_tiers.
+func convertKey(key string, keyType reflect.Type) (reflect.Value, error) {
+	switch keyType.Kind() {
+
+	case reflect.String:
+		return reflect.ValueOf(key).Convert(keyType), nil
+
+	case reflect.Int, reflect.Int64:
+		number, err := strconv.ParseInt(key, 10, keyType.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("Key %q is not a number", key)
+		}
+		return reflect.ValueOf(number).Convert(keyType), nil
+
+	default:
+		return reflect.Value{}, fmt.Errorf("Unsupported key type %s", keyType)
+	}
+}
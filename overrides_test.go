@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		wantErr  string
+	}{
+		{name: "valid set", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "set", "value": 1, "reason": "r"}`},
+		{name: "valid delete", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "delete", "reason": "r", "expires": "2026-01-31"}`},
+		{name: "set without value", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "set", "reason": "r"}`, wantErr: "has no value"},
+		{name: "unknown op", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "add", "value": 1, "reason": "r"}`, wantErr: "unknown op"},
+		{name: "no reason", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "delete"}`, wantErr: "has no reason"},
+		{name: "invalid expiry", override: `{"dataset": "market_ids/steam.json", "name": "a", "op": "delete", "reason": "r", "expires": "31.01.2026"}`, wantErr: "Invalid expiry"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "overrides.json")
+			config := `{"version": 1, "overrides": [` + test.override + `]}`
+			if err := os.WriteFile(filePath, []byte(config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := loadOverrides(filePath)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("loadOverrides() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	newCatalog := func() *Catalog {
+		return &Catalog{
+			SteamMarketIDs:  map[string]int{"AK-47 | Redline (Field-Tested)": 1},
+			Buff163PhaseIDs: map[string]map[string]int{"★ Karambit | Doppler (Factory New)": {"Phase 2": 10}},
+			PatternTiers:    map[string]SeedTiers{"AK-47 | Case Hardened": {661: "Tier 1"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		override Override
+		derived  bool
+		check    func(c *Catalog) any
+		want     any
+		warning  string
+		wantErr  string
+	}{
+		{
+			name:     "set creates an entry",
+			override: Override{Dataset: "market_ids/steam.json", Name: "New", Op: "set", Value: json.RawMessage(`2`)},
+			check:    func(c *Catalog) any { return c.SteamMarketIDs["New"] },
+			want:     2,
+		},
+		{
+			name:     "replace of a missing entry is redundant",
+			override: Override{Dataset: "market_ids/steam.json", Name: "New", Op: "replace", Value: json.RawMessage(`2`)},
+			check:    func(c *Catalog) any { _, exists := c.SteamMarketIDs["New"]; return exists },
+			want:     false,
+			warning:  "is redundant",
+		},
+		{
+			name:     "set of the upstream value is redundant",
+			override: Override{Dataset: "market_ids/steam.json", Name: "AK-47 | Redline (Field-Tested)", Op: "set", Value: json.RawMessage(`1`)},
+			check:    func(c *Catalog) any { return c.SteamMarketIDs["AK-47 | Redline (Field-Tested)"] },
+			want:     1,
+			warning:  "upstream already has 1",
+		},
+		{
+			name:     "delete",
+			override: Override{Dataset: "market_ids/steam.json", Name: "AK-47 | Redline (Field-Tested)", Op: "delete"},
+			check:    func(c *Catalog) any { return len(c.SteamMarketIDs) },
+			want:     0,
+		},
+		{
+			name:     "nested key",
+			override: Override{Dataset: "buff163_grouped_ids/phases.json", Name: "★ Karambit | Doppler (Factory New)", Key: "Ruby", Op: "set", Value: json.RawMessage(`11`)},
+			check:    func(c *Catalog) any { return c.Buff163PhaseIDs["★ Karambit | Doppler (Factory New)"]["Ruby"] },
+			want:     11,
+		},
+		{
+			name:     "expired override is skipped",
+			override: Override{Dataset: "market_ids/steam.json", Name: "New", Op: "set", Value: json.RawMessage(`2`), expires: time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)},
+			check:    func(c *Catalog) any { _, exists := c.SteamMarketIDs["New"]; return exists },
+			want:     false,
+			warning:  "expired",
+		},
+		{
+			name:     "missing dataset is skipped",
+			override: Override{Dataset: "market_ids/igxe.json", Name: "New", Op: "set", Value: json.RawMessage(`2`)},
+			check:    func(c *Catalog) any { return c.IGXEIDs == nil },
+			want:     true,
+			warning:  "market_ids/igxe.json is missing from this run",
+		},
+		{
+			name:     "derived dataset waits for the derived pass",
+			override: Override{Dataset: "buff163_grouped_ids/pattern_tiers.json", Name: "AK-47 | Case Hardened", Key: "955", Op: "set", Value: json.RawMessage(`"Tier 2"`)},
+			check:    func(c *Catalog) any { _, exists := c.PatternTiers["AK-47 | Case Hardened"][955]; return exists },
+			want:     false,
+		},
+		{
+			name:     "int keyed derived dataset",
+			override: Override{Dataset: "buff163_grouped_ids/pattern_tiers.json", Name: "AK-47 | Case Hardened", Key: "955", Op: "set", Value: json.RawMessage(`"Tier 2"`)},
+			derived:  true,
+			check:    func(c *Catalog) any { return c.PatternTiers["AK-47 | Case Hardened"][955] },
+			want:     "Tier 2",
+		},
+		{
+			name:     "int key that is not a number",
+			override: Override{Dataset: "buff163_grouped_ids/pattern_tiers.json", Name: "AK-47 | Case Hardened", Key: "blue", Op: "delete"},
+			derived:  true,
+			wantErr:  `Key "blue" is not a number`,
+		},
+		{
+			name:     "unknown dataset",
+			override: Override{Dataset: "market_ids/unknown.json", Name: "a", Op: "delete"},
+			wantErr:  "unknown dataset",
+		},
+		{
+			name:     "key on a flat dataset",
+			override: Override{Dataset: "market_ids/steam.json", Name: "AK-47 | Redline (Field-Tested)", Key: "x", Op: "delete"},
+			wantErr:  "is not nested",
+		},
+		{
+			name:     "value of the wrong type",
+			override: Override{Dataset: "market_ids/steam.json", Name: "New", Op: "set", Value: json.RawMessage(`"2"`)},
+			wantErr:  "Invalid value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			catalog := newCatalog()
+			config := &OverrideConfig{Version: overridesVersion, Overrides: []Override{test.override}}
+
+			warnings, err := config.apply(catalog, now, test.derived)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("apply() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := test.check(catalog); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+
+			if test.warning == "" && len(warnings) > 0 {
+				t.Errorf("apply() warnings = %q, want none", warnings)
+			}
+			if test.warning != "" && (len(warnings) != 1 || !strings.Contains(warnings[0], test.warning)) {
+				t.Errorf("apply() warnings = %q, want %q", warnings, test.warning)
+			}
+		})
+	}
+}
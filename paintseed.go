@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResolvePaintseedGroup returns the BUFF.163 paintseed group an item with the
+// given paint seed is listed under, e.g. "Fire&Ice 1st" for a Marble Fade,
+// and the group's goods sub-ID. Groups are matched to seeds through the
+// pattern seed lists.
+func (c *Catalog) ResolvePaintseedGroup(marketHashName string, seed int) (string, int, bool) {
+	group, exists := c.PatternTier(marketHashName, seed)
+	if !exists {
+		return "", 0, false
+	}
+
+	id, exists := c.Buff163PaintseedGroupIDs[marketHashName][group]
+	return group, id, exists
+}
+
+// UnseededPaintseedGroups returns the BUFF.163 paintseed groups of every
+// market_hash_name that have no seed list, so they can never be resolved from
+// a paint seed.
+func (c *Catalog) UnseededPaintseedGroups() map[string][]string {
+	unseeded := make(map[string][]string)
+
+	for _, name := range sortedKeys(c.Buff163PaintseedGroupIDs) {
+		key, _ := c.patternName(name)
+		for _, group := range sortedKeys(c.Buff163PaintseedGroupIDs[name]) {
+			if _, exists := c.Buff163Patterns[key][group]; !exists {
+				unseeded[name] = append(unseeded[name], group)
+			}
+		}
+	}
+
+	return unseeded
+}
+
+func runPaintseed(args []string) error {
+	flags := flag.NewFlagSet("paintseed", flag.ExitOnError)
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	unseeded := flags.Bool("unseeded", false, "list the paintseed groups that have no seed list")
+	flags.Parse(args)
+
+	catalog, err := loadCatalog(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *unseeded {
+		groups := catalog.UnseededPaintseedGroups()
+		for _, name := range sortedKeys(groups) {
+			fmt.Printf("%s: %s\n", name, strings.Join(groups[name], ", "))
+		}
+		return nil
+	}
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("Usage: paintseed <market_hash_name> <seed>")
+	}
+
+	seed, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("Invalid paint seed %s", flags.Arg(1))
+	}
+
+	group, id, exists := catalog.ResolvePaintseedGroup(flags.Arg(0), seed)
+	if !exists {
+		return fmt.Errorf("Paint seed %d of %s has no BUFF.163 paintseed group", seed, flags.Arg(0))
+	}
+	fmt.Printf("%s %d\n", group, id)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvePaintseedGroup(t *testing.T) {
+	patterns := map[string]map[string][]int{
+		"★ Bayonet | Case Hardened": {"Blue Gem": {179, 555}, "Gold": {555, 700}},
+	}
+	catalog := &Catalog{
+		Buff163Patterns: patterns,
+		PatternTiers:    buildPatternTiers(patterns),
+		Buff163PaintseedGroupIDs: map[string]map[string]int{
+			"★ Bayonet | Case Hardened (Field-Tested)": {"Blue Gem": 52},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		marketHashName string
+		seed           int
+		group          string
+		id             int
+		found          bool
+	}{
+		{name: "listed seed", marketHashName: "★ Bayonet | Case Hardened (Field-Tested)", seed: 179, group: "Blue Gem", id: 52, found: true},
+		{name: "seed in several tiers gets the first", marketHashName: "★ Bayonet | Case Hardened (Field-Tested)", seed: 555, group: "Blue Gem", id: 52, found: true},
+		{name: "tier without a group ID", marketHashName: "★ Bayonet | Case Hardened (Field-Tested)", seed: 700, group: "Gold", found: false},
+		{name: "unlisted seed", marketHashName: "★ Bayonet | Case Hardened (Field-Tested)", seed: 1, found: false},
+		{name: "other wear without groups", marketHashName: "★ Bayonet | Case Hardened (Factory New)", seed: 179, group: "Blue Gem", found: false},
+		{name: "skin without patterns", marketHashName: "AK-47 | Redline (Field-Tested)", seed: 179, found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group, id, found := catalog.ResolvePaintseedGroup(test.marketHashName, test.seed)
+			if group != test.group || id != test.id || found != test.found {
+				t.Errorf("ResolvePaintseedGroup(%q, %d) = %q, %d, %t, want %q, %d, %t",
+					test.marketHashName, test.seed, group, id, found, test.group, test.id, test.found)
+			}
+		})
+	}
+}
+
+func TestUnseededPaintseedGroups(t *testing.T) {
+	catalog := &Catalog{
+		Buff163Patterns: map[string]map[string][]int{
+			"★ Bayonet | Case Hardened": {"Blue Gem": {179}},
+			"★ Karambit | Marble Fade":  {"Fire&Ice 1st": {412}},
+		},
+		Buff163PaintseedGroupIDs: map[string]map[string]int{
+			"★ Bayonet | Case Hardened (Field-Tested)":         {"Blue Gem": 52, "Gold": 53},
+			"★ Karambit | Marble Fade (Factory New)":           {"Fire&Ice 1st": 60},
+			"★ StatTrak™ Karambit | Marble Fade (Factory New)": {"Fire&Ice 1st": 61, "Fake FI": 62},
+			"★ Gut Knife | Marble Fade (Factory New)":          {"Fire&Ice 1st": 70},
+		},
+	}
+
+	want := map[string][]string{
+		"★ Bayonet | Case Hardened (Field-Tested)":         {"Gold"},
+		"★ StatTrak™ Karambit | Marble Fade (Factory New)": {"Fake FI"},
+		"★ Gut Knife | Marble Fade (Factory New)":          {"Fire&Ice 1st"},
+	}
+	if got := catalog.UnseededPaintseedGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnseededPaintseedGroups() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"steamSkinIDs/pkg/patterns"
+)
+
+// SeedTiers maps the paint seeds of one skin to their pattern tier.
+type SeedTiers map[int]string
+
+func (SeedTiers) columns() []string {
+	return []string{"seed", "tier"}
+}
+
+func (tiers SeedTiers) rows() [][]string {
+	seeds := make([]int, 0, len(tiers))
+	for seed := range tiers {
+		seeds = append(seeds, seed)
+	}
+	sort.Ints(seeds)
+
+	rows := make([][]string, 0, len(seeds))
+	for _, seed := range seeds {
+		rows = append(rows, []string{strconv.Itoa(seed), tiers[seed]})
+	}
+	return rows
+}
+
+// buildPatternTiers inverts patterns into name → paint seed → tier. A seed
+// listed under several tiers of a skin gets the first tier by name.
+func buildPatternTiers(seedLists map[string]map[string][]int) map[string]SeedTiers {
+	if seedLists == nil {
+		return nil
+	}
+
+	tiers := make(map[string]SeedTiers, len(seedLists))
+	for name, seedsByTier := range seedLists {
+		tiers[name] = patterns.Invert(seedsByTier)
+	}
+
+	return tiers
+}
+
+// patternName finds the key patterns uses for an item.
+func (c *Catalog) patternName(name string) (string, bool) {
+	return patterns.Key(c.Buff163Patterns, name)
+}
+
+// PatternTier returns the BUFF.163 pattern tier of a paint seed, e.g.
+// "Tier 1" for a blue gem AK-47 | Case Hardened. The name can be a skin
+// name or any market_hash_name of the skin.
+func (c *Catalog) PatternTier(name string, seed int) (string, bool) {
+	key, exists := c.patternName(name)
+	if !exists {
+		return "", false
+	}
+
+	tier, exists := c.PatternTiers[key][seed]
+	return tier, exists
+}
+
+// TierSeeds returns the paint seeds of a pattern tier, sorted.
+func (c *Catalog) TierSeeds(name string, tier string) []int {
+	return patterns.TierSeeds(c.Buff163Patterns, name, tier)
+}
+
+func runPattern(args []string) error {
+	flags := flag.NewFlagSet("pattern", flag.ExitOnError)
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	tier := flags.String("tier", "", "print the paint seeds of this tier instead")
+	flags.Parse(args)
+
+	catalog, err := loadCatalog(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *tier != "" {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("Usage: pattern -tier <tier> <name>")
+		}
+
+		seeds := catalog.TierSeeds(flags.Arg(0), *tier)
+		if len(seeds) == 0 {
+			return fmt.Errorf("No paint seeds for %s %s", flags.Arg(0), *tier)
+		}
+
+		values := make([]string, 0, len(seeds))
+		for _, seed := range seeds {
+			values = append(values, strconv.Itoa(seed))
+		}
+		fmt.Println(strings.Join(values, " "))
+
+		return nil
+	}
+
+	if flags.NArg() != 2 {
+		return fmt.Errorf("Usage: pattern <name> <seed>")
+	}
+
+	seed, err := strconv.Atoi(flags.Arg(1))
+	if err != nil {
+		return fmt.Errorf("Invalid paint seed %s", flags.Arg(1))
+	}
+
+	tierName, exists := catalog.PatternTier(flags.Arg(0), seed)
+	if !exists {
+		return fmt.Errorf("Paint seed %d of %s has no tier", seed, flags.Arg(0))
+	}
+	fmt.Println(tierName)
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"steamSkinIDs/pkg/itemname"
+)
+
+// Phase ties a Doppler or Gamma Doppler phase of a skin to its paint index
+// and to the BUFF.163 phase listing of every wear of that skin, keyed by
+// market_hash_name.
+type Phase struct {
+	PaintIndex int            `json:"paint_index"`
+	Buff163IDs map[string]int `json:"buff163_ids"`
+}
+
+// SkinPhases holds the phases of one skin, keyed by phase name.
+type SkinPhases map[string]Phase
+
+func (SkinPhases) columns() []string {
+	return []string{"phase", "paint_index", "market_hash_name", "buff163_id"}
+}
+
+// rows has one row per BUFF.163 listing of a phase, or a single row without
+// a listing when BUFF.163 has none.
+func (phases SkinPhases) rows() [][]string {
+	var rows [][]string
+	for _, phase := range sortedKeys(phases) {
+		entry := phases[phase]
+		paintIndex := strconv.Itoa(entry.PaintIndex)
+		if len(entry.Buff163IDs) == 0 {
+			rows = append(rows, []string{phase, paintIndex, "", ""})
+		}
+		for _, marketHashName := range sortedKeys(entry.Buff163IDs) {
+			rows = append(rows, []string{phase, paintIndex, marketHashName, strconv.Itoa(entry.Buff163IDs[marketHashName])})
+		}
+	}
+	return rows
+}
+
+// buildPhases joins the "Karambit | Doppler Phase 2" paint indexes with the
+// wear-qualified BUFF.163 phase IDs into skin name → phase → Phase.
+func buildPhases(paintIndexes map[string]int, buff163PhaseIDs map[string]map[string]int) map[string]SkinPhases {
+	if paintIndexes == nil || buff163PhaseIDs == nil {
+		return nil
+	}
+
+	phaseNames := make(map[string]struct{})
+	for _, ids := range buff163PhaseIDs {
+		for phase := range ids {
+			phaseNames[phase] = struct{}{}
+		}
+	}
+
+	phases := make(map[string]SkinPhases)
+
+	for key, paintIndex := range paintIndexes {
+		for phase := range phaseNames {
+			skin, found := strings.CutSuffix(key, " "+phase)
+			if !found {
+				continue
+			}
+
+			if phases[skin] == nil {
+				phases[skin] = make(SkinPhases)
+			}
+			phases[skin][phase] = Phase{PaintIndex: paintIndex, Buff163IDs: make(map[string]int)}
+			break
+		}
+	}
+
+	for name, ids := range buff163PhaseIDs {
+		skin := itemname.SkinName(name)
+		for phase, id := range ids {
+			if entry, exists := phases[skin][phase]; exists {
+				entry.Buff163IDs[name] = id
+			}
+		}
+	}
+
+	return phases
+}
+
+// ResolvePhase returns the phase of an item given its paint index, and the
+// BUFF.163 phase ID of that phase for the item's market_hash_name, or 0 when
+// BUFF.163 has no listing for it.
+func (c *Catalog) ResolvePhase(marketHashName string, paintIndex int) (string, int, bool) {
+	for phase, entry := range c.Phases[itemname.SkinName(marketHashName)] {
+		if entry.PaintIndex == paintIndex {
+			return phase, entry.Buff163IDs[marketHashName], true
+		}
+	}
+
+	return "", 0, false
+}
+
+// PhasePaintIndex returns the paint index of a phase of an item.
+func (c *Catalog) PhasePaintIndex(marketHashName string, phase string) (int, bool) {
+	entry, exists := c.Phases[itemname.SkinName(marketHashName)][phase]
+	return entry.PaintIndex, exists
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildPhases(t *testing.T) {
+	paintIndexes := map[string]int{
+		"Karambit | Doppler Phase 2": 419,
+		"Karambit | Doppler Ruby":    415,
+		"Karambit | Fade":            38,
+	}
+	buff163PhaseIDs := map[string]map[string]int{
+		"★ Karambit | Doppler (Factory New)":  {"Phase 2": 1002, "Ruby": 1005},
+		"★ Karambit | Doppler (Minimal Wear)": {"Phase 2": 2002, "Emerald": 2009},
+	}
+
+	tests := []struct {
+		name         string
+		paintIndexes map[string]int
+		phaseIDs     map[string]map[string]int
+		want         map[string]SkinPhases
+	}{
+		{
+			name:         "joins paint indexes with listings",
+			paintIndexes: paintIndexes,
+			phaseIDs:     buff163PhaseIDs,
+			want: map[string]SkinPhases{
+				"Karambit | Doppler": {
+					"Phase 2": {PaintIndex: 419, Buff163IDs: map[string]int{"★ Karambit | Doppler (Factory New)": 1002, "★ Karambit | Doppler (Minimal Wear)": 2002}},
+					"Ruby":    {PaintIndex: 415, Buff163IDs: map[string]int{"★ Karambit | Doppler (Factory New)": 1005}},
+				},
+			},
+		},
+		{name: "missing paint indexes", paintIndexes: nil, phaseIDs: buff163PhaseIDs, want: nil},
+		{name: "missing phase IDs", paintIndexes: paintIndexes, phaseIDs: nil, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := buildPhases(test.paintIndexes, test.phaseIDs); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("buildPhases() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolvePhase(t *testing.T) {
+	catalog := &Catalog{Phases: map[string]SkinPhases{
+		"Karambit | Doppler": {
+			"Phase 2": {PaintIndex: 419, Buff163IDs: map[string]int{"★ Karambit | Doppler (Factory New)": 1002}},
+			"Ruby":    {PaintIndex: 415, Buff163IDs: map[string]int{}},
+		},
+	}}
+
+	tests := []struct {
+		name           string
+		marketHashName string
+		paintIndex     int
+		phase          string
+		id             int
+		found          bool
+	}{
+		{"listed phase", "★ Karambit | Doppler (Factory New)", 419, "Phase 2", 1002, true},
+		{"StatTrak shares the phases", "★ StatTrak™ Karambit | Doppler (Factory New)", 419, "Phase 2", 0, true},
+		{"phase without listing", "★ Karambit | Doppler (Factory New)", 415, "Ruby", 0, true},
+		{"unknown paint index", "★ Karambit | Doppler (Factory New)", 1, "", 0, false},
+		{"skin without phases", "★ Karambit | Fade (Factory New)", 38, "", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			phase, id, found := catalog.ResolvePhase(test.marketHashName, test.paintIndex)
+			if phase != test.phase || id != test.id || found != test.found {
+				t.Errorf("ResolvePhase(%q, %d) = %q, %d, %t, want %q, %d, %t", test.marketHashName, test.paintIndex, phase, id, found, test.phase, test.id, test.found)
+			}
+		})
+	}
+
+	if paintIndex, exists := catalog.PhasePaintIndex("★ Karambit | Doppler (Minimal Wear)", "Ruby"); !exists || paintIndex != 415 {
+		t.Errorf("PhasePaintIndex() = %d, %t, want 415, true", paintIndex, exists)
+	}
+}
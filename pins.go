@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	rawGitHubBaseURL = "https://raw.githubusercontent.com/"
+	gitHubAPIBaseURL = "https://api.github.com/repos/"
+
+	lockVersion = 1
+)
+
+// upstream is a repository the generator fetches from and the branch the
+// base URLs point at.
+type upstream struct {
+	repo string
+	ref  string
+}
+
+var upstreams = []upstream{
+	{byMykelRepo, byMykelRef},
+	{ericZhuRepo, ericZhuRef},
+	{modestSerhatRepo, modestSerhatRef},
+}
+
+// upstreamPins maps a repository to the commit its URLs are rewritten to,
+// see pinURL. It is empty unless a lock file was loaded.
+var upstreamPins = map[string]string{}
+
+// LockedUpstream is the commit a branch of an upstream resolved to.
+type LockedUpstream struct {
+	Ref        string    `json:"ref"`
+	Commit     string    `json:"commit"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+type LockFile struct {
+	Version   int                       `json:"version"`
+	Upstreams map[string]LockedUpstream `json:"upstreams"`
+}
+
+// Provenance records where the datasets of a run came from. Commit is empty
+// when the upstream was not pinned and its branch was fetched.
+type Provenance struct {
+	Ref    string `json:"ref"`
+	Commit string `json:"commit,omitempty"`
+}
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadLock reads a lock file, a missing file leaves every upstream unpinned.
+func loadLock(filePath string) (*LockFile, error) {
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &LockFile{Version: lockVersion, Upstreams: map[string]LockedUpstream{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read lock file %s: %w", filePath, err)
+	}
+
+	var lock LockFile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("Failed to decode lock file %s: %w", filePath, err)
+	}
+
+	if lock.Version != lockVersion {
+		return nil, fmt.Errorf("Unsupported lock file version %d in %s, expected %d", lock.Version, filePath, lockVersion)
+	}
+
+	for repo, locked := range lock.Upstreams {
+		if !commitPattern.MatchString(locked.Commit) {
+			return nil, fmt.Errorf("Invalid commit %q for %s in %s", locked.Commit, repo, filePath)
+		}
+	}
+
+	return &lock, nil
+}
+
+// pin rewrites the URLs of every locked upstream to its commit and returns
+// the provenance of all upstreams.
+func (lock *LockFile) pin() map[string]Provenance {
+	provenance := make(map[string]Provenance, len(upstreams))
+
+	for _, u := range upstreams {
+		locked, exists := lock.Upstreams[u.repo]
+		if !exists || locked.Ref != u.ref {
+			fmt.Printf("%s is not pinned, fetching %s\n", u.repo, u.ref)
+			provenance[u.repo] = Provenance{Ref: u.ref}
+			continue
+		}
+
+		upstreamPins[u.repo] = locked.Commit
+		provenance[u.repo] = Provenance{Ref: u.ref, Commit: locked.Commit}
+	}
+
+	return provenance
+}
+
+// pinURL rewrites a raw URL on the branch of a pinned upstream to the same
+// path at the pinned commit.
+func pinURL(url string) string {
+	for _, u := range upstreams {
+		commit, pinned := upstreamPins[u.repo]
+		if !pinned {
+			continue
+		}
+
+		prefix := rawGitHubBaseURL + u.repo + "/" + u.ref + "/"
+		if path, found := strings.CutPrefix(url, prefix); found {
+			return rawGitHubBaseURL + u.repo + "/" + commit + "/" + path
+		}
+	}
+
+	return url
+}
+
+// resolveCommit asks GitHub for the commit a branch currently points at,
+// authenticated with GITHUB_TOKEN when it is set.
+func resolveCommit(ctx context.Context, repo, ref string) (string, error) {
+	url := gitHubAPIBaseURL + repo + "/commits/" + ref
+
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create request for URL %s: %w", url, err)
+	}
+	request.Header.Set("Accept", "application/vnd.github.sha")
+	request.Header.Set("User-Agent", defaultHeaders.Get("User-Agent"))
+	// Unauthenticated requests share the rate limit of the runner's IP.
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := defaultHttpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Request execution failed for URL %s: %w", url, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unexpected status code for URL %s: %d", url, response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<10))
+	if err != nil {
+		return "", fmt.Errorf("Failed to read response body for URL %s: %w", url, err)
+	}
+
+	commit := strings.TrimSpace(string(body))
+	if !commitPattern.MatchString(commit) {
+		return "", fmt.Errorf("Unexpected commit %q for URL %s", commit, url)
+	}
+
+	return commit, nil
+}
+
+func runLock(args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ExitOnError)
+	lockPath := flags.String("lock", "sources.lock.json", "path to the lock file")
+	timeout := flags.Duration("timeout", time.Minute, "deadline for resolving all upstreams")
+	flags.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	lock := LockFile{Version: lockVersion, Upstreams: make(map[string]LockedUpstream, len(upstreams))}
+
+	for _, u := range upstreams {
+		commit, err := resolveCommit(ctx, u.repo, u.ref)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve %s %s: %w", u.repo, u.ref, err)
+		}
+
+		lock.Upstreams[u.repo] = LockedUpstream{Ref: u.ref, Commit: commit, ResolvedAt: time.Now().UTC()}
+		fmt.Printf("%s %s %s\n", u.repo, u.ref, commit)
+	}
+
+	var output outputSet
+
+	err := writeFile(&output, *lockPath, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(lock)
+	})
+	if err != nil {
+		return err
+	}
+
+	return output.commit(ctx)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPinURL(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	previous := upstreamPins
+	upstreamPins = map[string]string{byMykelRepo: commit}
+	t.Cleanup(func() { upstreamPins = previous })
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "pinned upstream",
+			url:  byMykelAPIBaseURL + "skins.json",
+			want: rawGitHubBaseURL + byMykelRepo + "/" + commit + "/public/api/en/skins.json",
+		},
+		{
+			name: "unpinned upstream",
+			url:  ericZhuAPIBaseURL + "buff" + counterStrikeJSON,
+			want: ericZhuAPIBaseURL + "buff" + counterStrikeJSON,
+		},
+		{
+			name: "other branch of a pinned upstream",
+			url:  rawGitHubBaseURL + byMykelRepo + "/dev/public/api/en/skins.json",
+			want: rawGitHubBaseURL + byMykelRepo + "/dev/public/api/en/skins.json",
+		},
+		{
+			name: "repository sharing a prefix",
+			url:  rawGitHubBaseURL + byMykelRepo + "-fork/" + byMykelRef + "/skins.json",
+			want: rawGitHubBaseURL + byMykelRepo + "-fork/" + byMykelRef + "/skins.json",
+		},
+		{
+			name: "other host",
+			url:  "https://example.com/" + byMykelRepo + "/" + byMykelRef + "/skins.json",
+			want: "https://example.com/" + byMykelRepo + "/" + byMykelRef + "/skins.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pinURL(test.url); got != test.want {
+				t.Errorf("pinURL(%q) = %q, want %q", test.url, got, test.want)
+			}
+		})
+	}
+}
+
+// Every URL the generator fetches has to belong to an upstream, otherwise
+// the lock file cannot pin it.
+func TestSourcesBelongToUpstreams(t *testing.T) {
+	for url := range sourceLimits {
+		found := false
+		for _, u := range upstreams {
+			if strings.HasPrefix(url, rawGitHubBaseURL+u.repo+"/"+u.ref+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s does not belong to any upstream", url)
+		}
+	}
+}
+
+func TestLoadLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		lock    string
+		pinned  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "pins locked upstreams on their branch",
+			lock:   `{"version": 1, "upstreams": {"` + byMykelRepo + `": {"ref": "` + byMykelRef + `", "commit": "0123456789abcdef0123456789abcdef01234567"}, "` + ericZhuRepo + `": {"ref": "other", "commit": "89abcdef0123456789abcdef0123456789abcdef"}}}`,
+			pinned: map[string]string{byMykelRepo: "0123456789abcdef0123456789abcdef01234567"},
+		},
+		{
+			name:    "unsupported version",
+			lock:    `{"version": 2, "upstreams": {}}`,
+			wantErr: "Unsupported lock file version",
+		},
+		{
+			name:    "invalid commit",
+			lock:    `{"version": 1, "upstreams": {"` + byMykelRepo + `": {"ref": "main", "commit": "main"}}}`,
+			wantErr: "Invalid commit",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previous := upstreamPins
+			upstreamPins = map[string]string{}
+			t.Cleanup(func() { upstreamPins = previous })
+
+			filePath := filepath.Join(t.TempDir(), "sources.lock.json")
+			if err := os.WriteFile(filePath, []byte(test.lock), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			lock, err := loadLock(filePath)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("loadLock() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			provenance := lock.pin()
+			if !maps.Equal(upstreamPins, test.pinned) {
+				t.Errorf("pinned %v, want %v", upstreamPins, test.pinned)
+			}
+			for _, u := range upstreams {
+				if provenance[u.repo].Commit != test.pinned[u.repo] {
+					t.Errorf("provenance of %s = %+v, want commit %q", u.repo, provenance[u.repo], test.pinned[u.repo])
+				}
+			}
+		})
+	}
+
+	previous := upstreamPins
+	upstreamPins = map[string]string{}
+	t.Cleanup(func() { upstreamPins = previous })
+
+	lock, err := loadLock(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.pin()
+	if len(upstreamPins) != 0 {
+		t.Errorf("a missing lock file pinned %v", upstreamPins)
+	}
+}
+
+// roundTripFunc answers requests without a network.
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestResolveCommitToken(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{name: "with token", token: "secret", want: "Bearer secret"},
+		{name: "without token", token: "", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GITHUB_TOKEN", test.token)
+
+			var authorization string
+			previous := defaultHttpClient
+			defaultHttpClient = &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+				authorization = request.Header.Get("Authorization")
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(commit + "\n"))}, nil
+			})}
+			t.Cleanup(func() { defaultHttpClient = previous })
+
+			got, err := resolveCommit(context.Background(), byMykelRepo, byMykelRef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != commit {
+				t.Errorf("resolveCommit() = %q, want %q", got, commit)
+			}
+			if authorization != test.want {
+				t.Errorf("Authorization = %q, want %q", authorization, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package itemname splits CS2 market_hash_names into the parts the datasets
+// are keyed by.
+package itemname
+
+import "strings"
+
+// Wears are the exteriors a market_hash_name can end with, best first.
+var Wears = []string{"Factory New", "Minimal Wear", "Field-Tested", "Well-Worn", "Battle-Scarred"}
+
+// SplitWear splits the wear off a market_hash_name, so "AK-47 | Redline
+// (Field-Tested)" becomes "AK-47 | Redline" and "Field-Tested". wear is
+// empty when the name has none.
+func SplitWear(marketHashName string) (name, wear string) {
+	start := strings.LastIndex(marketHashName, " (")
+	if start == -1 || !strings.HasSuffix(marketHashName, ")") {
+		return marketHashName, ""
+	}
+
+	for _, candidate := range Wears {
+		if marketHashName[start+2:len(marketHashName)-1] == candidate {
+			return marketHashName[:start], candidate
+		}
+	}
+
+	return marketHashName, ""
+}
+
+// SkinName strips the star, StatTrak and Souvenir prefixes and the wear of a
+// market_hash_name, so "★ StatTrak™ Karambit | Doppler (Factory New)" becomes
+// "Karambit | Doppler", the way paint_indexes and patterns are keyed.
+func SkinName(marketHashName string) string {
+	name := strings.TrimPrefix(marketHashName, "★ ")
+	name = strings.TrimPrefix(name, "StatTrak™ ")
+	name = strings.TrimPrefix(name, "Souvenir ")
+
+	name, _ = SplitWear(name)
+
+	return name
+}
@@ -0,0 +1,44 @@
+package itemname
+
+import "testing"
+
+func TestSkinName(t *testing.T) {
+	tests := []struct {
+		marketHashName string
+		want           string
+	}{
+		{"★ StatTrak™ Karambit | Doppler (Factory New)", "Karambit | Doppler"},
+		{"Souvenir AWP | Dragon Lore (Field-Tested)", "AWP | Dragon Lore"},
+		{"AK-47 | Case Hardened (Battle-Scarred)", "AK-47 | Case Hardened"},
+		{"★ Karambit", "Karambit"},
+		{"Sticker | Crown (Foil)", "Sticker | Crown (Foil)"},
+	}
+
+	for _, test := range tests {
+		if got := SkinName(test.marketHashName); got != test.want {
+			t.Errorf("SkinName(%q) = %q, want %q", test.marketHashName, got, test.want)
+		}
+	}
+}
+
+func TestSplitWear(t *testing.T) {
+	tests := []struct {
+		marketHashName string
+		name           string
+		wear           string
+	}{
+		{"AK-47 | Redline (Field-Tested)", "AK-47 | Redline", "Field-Tested"},
+		{"★ Karambit | Doppler (Factory New)", "★ Karambit | Doppler", "Factory New"},
+		{"Sticker | Crown (Foil)", "Sticker | Crown (Foil)", ""},
+		{"Operation Breakout Weapon Case", "Operation Breakout Weapon Case", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.marketHashName, func(t *testing.T) {
+			name, wear := SplitWear(test.marketHashName)
+			if name != test.name || wear != test.wear {
+				t.Errorf("SplitWear(%q) = %q, %q, want %q, %q", test.marketHashName, name, wear, test.name, test.wear)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package listings builds the listing URLs of an item on Steam and the
+// Chinese marketplaces from its market IDs.
+package listings
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Item is what URLs needs to know about one market_hash_name. MarketIDs is
+// keyed like market_ids ("steam", "buff163", ...), the BUFF.163 sub-ID maps
+// are keyed by phase, tag and paintseed group like buff163_grouped_ids.
+type Item struct {
+	MarketHashName           string
+	MarketIDs                map[string]int
+	Buff163PhaseIDs          map[string]int
+	Buff163TagIDs            map[string]int
+	Buff163PaintseedGroupIDs map[string]int
+}
+
+// Filter narrows a listing to a Doppler phase, a BUFF.163 tag or a BUFF.163
+// paintseed group. Only BUFF.163 has sub-IDs for them, the other
+// marketplaces always link to the whole listing.
+type Filter struct {
+	Phase          string
+	Tag            string
+	PaintseedGroup string
+}
+
+// URLs returns the listing URL of an item on every marketplace that has it,
+// keyed like market_ids.
+func URLs(item Item, filter Filter) (map[string]string, error) {
+	escapedName := url.PathEscape(item.MarketHashName)
+
+	urls := make(map[string]string)
+
+	if _, exists := item.MarketIDs["steam"]; exists {
+		urls["steam"] = "https://steamcommunity.com/market/listings/730/" + escapedName
+	}
+
+	if id, exists := item.MarketIDs["buff163"]; exists {
+		fragment := url.Values{"tab": {"selling"}}
+
+		subFilters := []struct {
+			kind  string
+			value string
+			ids   map[string]int
+			key   string
+		}{
+			{"phase", filter.Phase, item.Buff163PhaseIDs, "tag_ids"},
+			{"tag", filter.Tag, item.Buff163TagIDs, "tag_ids"},
+			{"paintseed group", filter.PaintseedGroup, item.Buff163PaintseedGroupIDs, "paintseed_group_id"},
+		}
+		for _, subFilter := range subFilters {
+			if subFilter.value == "" {
+				continue
+			}
+			subID, exists := subFilter.ids[subFilter.value]
+			if !exists {
+				return nil, fmt.Errorf("No BUFF.163 %s %s for %s", subFilter.kind, subFilter.value, item.MarketHashName)
+			}
+			fragment.Add(subFilter.key, strconv.Itoa(subID))
+		}
+
+		urls["buff163"] = "https://buff.163.com/goods/" + strconv.Itoa(id) + "?from=market#" + fragment.Encode()
+	}
+
+	if id, exists := item.MarketIDs["buff_market"]; exists {
+		urls["buff_market"] = "https://buff.market/market/goods/" + strconv.Itoa(id)
+	}
+
+	if id, exists := item.MarketIDs["c5game"]; exists {
+		urls["c5game"] = "https://www.c5game.com/csgo/" + strconv.Itoa(id) + "/" + escapedName + "/sell"
+	}
+
+	if id, exists := item.MarketIDs["youpin898"]; exists {
+		urls["youpin898"] = "https://youpin898.com/goodInfo?id=" + strconv.Itoa(id)
+	}
+
+	if id, exists := item.MarketIDs["igxe"]; exists {
+		urls["igxe"] = "https://www.igxe.cn/product/730/" + strconv.Itoa(id)
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("No marketplace lists %s", item.MarketHashName)
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,78 @@
+package listings
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestURLs(t *testing.T) {
+	doppler := Item{
+		MarketHashName:           "★ Karambit | Doppler (Factory New)",
+		MarketIDs:                map[string]int{"steam": 1, "buff163": 43000, "buff_market": 2, "c5game": 3, "youpin898": 4, "igxe": 5},
+		Buff163PhaseIDs:          map[string]int{"Phase 2": 1002},
+		Buff163TagIDs:            map[string]int{"Sticker": 7},
+		Buff163PaintseedGroupIDs: map[string]int{"Blue Gem": 9},
+	}
+
+	tests := []struct {
+		name    string
+		item    Item
+		filter  Filter
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "every marketplace",
+			item: doppler,
+			want: map[string]string{
+				"steam":       "https://steamcommunity.com/market/listings/730/%E2%98%85%20Karambit%20%7C%20Doppler%20%28Factory%20New%29",
+				"buff163":     "https://buff.163.com/goods/43000?from=market#tab=selling",
+				"buff_market": "https://buff.market/market/goods/2",
+				"c5game":      "https://www.c5game.com/csgo/3/%E2%98%85%20Karambit%20%7C%20Doppler%20%28Factory%20New%29/sell",
+				"youpin898":   "https://youpin898.com/goodInfo?id=4",
+				"igxe":        "https://www.igxe.cn/product/730/5",
+			},
+		},
+		{
+			name:   "BUFF.163 phase and paintseed group",
+			item:   Item{MarketHashName: doppler.MarketHashName, MarketIDs: map[string]int{"buff163": 43000}, Buff163PhaseIDs: doppler.Buff163PhaseIDs, Buff163PaintseedGroupIDs: doppler.Buff163PaintseedGroupIDs},
+			filter: Filter{Phase: "Phase 2", PaintseedGroup: "Blue Gem"},
+			want:   map[string]string{"buff163": "https://buff.163.com/goods/43000?from=market#paintseed_group_id=9&tab=selling&tag_ids=1002"},
+		},
+		{
+			name:   "filters only narrow BUFF.163",
+			item:   Item{MarketHashName: "AK-47 | Redline (Field-Tested)", MarketIDs: map[string]int{"igxe": 5}},
+			filter: Filter{Phase: "Ruby"},
+			want:   map[string]string{"igxe": "https://www.igxe.cn/product/730/5"},
+		},
+		{
+			name:    "unknown BUFF.163 phase",
+			item:    doppler,
+			filter:  Filter{Phase: "Ruby"},
+			wantErr: true,
+		},
+		{
+			name:    "unlisted item",
+			item:    Item{MarketHashName: "Unknown"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			urls, err := URLs(test.item, test.filter)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("URLs() = %v, want an error", urls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(urls, test.want) {
+				t.Errorf("URLs() = %v, want %v", urls, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package marketids encodes and decodes binary/market_ids.pb, the protobuf
+// form of the six market_ids/*.json files.
+package marketids
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MarketIDs is the decoded form of binary/market_ids.pb. The wire format is
+// described by proto/market_ids.proto.
+type MarketIDs struct {
+	Steam      map[string]int
+	Buff163    map[string]int
+	BuffMarket map[string]int
+	C5Game     map[string]int
+	Youpin898  map[string]int
+	IGXE       map[string]int
+}
+
+// fields returns the maps in proto field number order, starting at 1.
+func (m *MarketIDs) fields() []*map[string]int {
+	return []*map[string]int{&m.Steam, &m.Buff163, &m.BuffMarket, &m.C5Game, &m.Youpin898, &m.IGXE}
+}
+
+// Encode encodes m in the wire format of binary/market_ids.pb. Entries are
+// written in name order, so equal maps always encode to the same bytes.
+func Encode(m *MarketIDs) []byte {
+	var b []byte
+
+	for i, field := range m.fields() {
+		number := protowire.Number(i + 1)
+		for _, name := range slices.Sorted(maps.Keys(*field)) {
+			var entry []byte
+			entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+			entry = protowire.AppendString(entry, name)
+			entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+			entry = protowire.AppendVarint(entry, uint64((*field)[name]))
+
+			b = protowire.AppendTag(b, number, protowire.BytesType)
+			b = protowire.AppendBytes(b, entry)
+		}
+	}
+
+	return b
+}
+
+// Decode decodes the contents of binary/market_ids.pb.
+func Decode(b []byte) (*MarketIDs, error) {
+	m := &MarketIDs{}
+	fields := m.fields()
+
+	counts, err := countEntries(b, len(fields))
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range fields {
+		*field = make(map[string]int, counts[i])
+	}
+
+	// Names are sliced out of a single copy of the input instead of being
+	// allocated one by one.
+	s := string(b)
+	offset := 0
+
+	for offset < len(s) {
+		number, wireType, n := protowire.ConsumeTag(b[offset:])
+		if n < 0 {
+			return nil, fmt.Errorf("Failed to decode field tag: %w", protowire.ParseError(n))
+		}
+		offset += n
+
+		if number < 1 || int(number) > len(fields) || wireType != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(number, wireType, b[offset:])
+			if n < 0 {
+				return nil, fmt.Errorf("Failed to skip field %d: %w", number, protowire.ParseError(n))
+			}
+			offset += n
+			continue
+		}
+
+		entry, n := protowire.ConsumeBytes(b[offset:])
+		if n < 0 {
+			return nil, fmt.Errorf("Failed to decode field %d: %w", number, protowire.ParseError(n))
+		}
+		entryOffset := offset + n - len(entry)
+		offset += n
+
+		name, id, err := decodeEntry(s[entryOffset:entryOffset+len(entry)], entry)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode entry of field %d: %w", number, err)
+		}
+		(*fields[number-1])[name] = id
+	}
+
+	return m, nil
+}
+
+func countEntries(b []byte, fieldCount int) ([]int, error) {
+	counts := make([]int, fieldCount)
+
+	for len(b) > 0 {
+		number, wireType, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, fmt.Errorf("Failed to decode field tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		n = protowire.ConsumeFieldValue(number, wireType, b)
+		if n < 0 {
+			return nil, fmt.Errorf("Failed to decode field %d: %w", number, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if number >= 1 && int(number) <= fieldCount && wireType == protowire.BytesType {
+			counts[number-1]++
+		}
+	}
+
+	return counts, nil
+}
+
+// decodeEntry decodes a map entry, s holds the same bytes as b.
+func decodeEntry(s string, b []byte) (string, int, error) {
+	var name string
+	var id int
+
+	offset := 0
+	for offset < len(b) {
+		number, wireType, n := protowire.ConsumeTag(b[offset:])
+		if n < 0 {
+			return "", 0, protowire.ParseError(n)
+		}
+		offset += n
+
+		switch {
+
+		case number == 1 && wireType == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b[offset:])
+			if n < 0 {
+				return "", 0, protowire.ParseError(n)
+			}
+			start := offset + n - len(value)
+			name = s[start : start+len(value)]
+			offset += n
+
+		case number == 2 && wireType == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b[offset:])
+			if n < 0 {
+				return "", 0, protowire.ParseError(n)
+			}
+			id = int(int64(value))
+			offset += n
+
+		default:
+			n := protowire.ConsumeFieldValue(number, wireType, b[offset:])
+			if n < 0 {
+				return "", 0, protowire.ParseError(n)
+			}
+			offset += n
+		}
+	}
+
+	return name, id, nil
+}
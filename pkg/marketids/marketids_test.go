@@ -0,0 +1,152 @@
+package marketids
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// files are the market_ids/*.json files in proto field number order.
+var files = []string{"steam.json", "buff163.json", "buff_market.json", "c5game.json", "youpin898.json", "igxe.json"}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  MarketIDs
+	}{
+		{
+			name: "empty",
+			ids:  MarketIDs{Steam: map[string]int{}, Buff163: map[string]int{}, BuffMarket: map[string]int{}, C5Game: map[string]int{}, Youpin898: map[string]int{}, IGXE: map[string]int{}},
+		},
+		{
+			name: "every marketplace",
+			ids: MarketIDs{
+				Steam:      map[string]int{"AK-47 | Redline (Field-Tested)": 1, "★ Karambit | Doppler (Factory New)": 2},
+				Buff163:    map[string]int{"AK-47 | Redline (Field-Tested)": 33974},
+				BuffMarket: map[string]int{"AK-47 | Redline (Field-Tested)": 4},
+				C5Game:     map[string]int{"AK-47 | Redline (Field-Tested)": 553370749},
+				Youpin898:  map[string]int{"AK-47 | Redline (Field-Tested)": 5},
+				IGXE:       map[string]int{"AK-47 | Redline (Field-Tested)": 6},
+			},
+		},
+		{
+			name: "large and negative IDs",
+			ids: MarketIDs{
+				Steam:      map[string]int{"big": 1 << 40, "negative": -1, "zero": 0},
+				Buff163:    map[string]int{},
+				BuffMarket: map[string]int{},
+				C5Game:     map[string]int{},
+				Youpin898:  map[string]int{},
+				IGXE:       map[string]int{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := Decode(Encode(&test.ids))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*decoded, test.ids) {
+				t.Errorf("Decode(Encode(ids)) = %v, want %v", *decoded, test.ids)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+	entry = protowire.AppendString(entry, "name")
+	entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+	entry = protowire.AppendVarint(entry, 7)
+
+	steam := protowire.AppendTag(nil, 1, protowire.BytesType)
+	steam = protowire.AppendBytes(steam, entry)
+
+	unknown := protowire.AppendTag(nil, 9, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		steam   map[string]int
+		wantErr bool
+	}{
+		{name: "empty input", data: nil, steam: map[string]int{}},
+		{name: "single entry", data: steam, steam: map[string]int{"name": 7}},
+		{name: "unknown fields are skipped", data: append(unknown, steam...), steam: map[string]int{"name": 7}},
+		{name: "truncated entry", data: steam[:len(steam)-1], wantErr: true},
+		{name: "invalid tag", data: []byte{0xff}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded, err := Decode(test.data)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("Decode() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Steam, test.steam) {
+				t.Errorf("Steam = %v, want %v", decoded.Steam, test.steam)
+			}
+		})
+	}
+}
+
+// loadMiniFiles reads the committed market_ids/*.json files.
+func loadMiniFiles(b *testing.B) ([][]byte, *MarketIDs) {
+	m := &MarketIDs{}
+	data := make([][]byte, len(files))
+	for i, field := range m.fields() {
+		var err error
+		data[i], err = os.ReadFile("../../mini/market_ids/" + files[i])
+		if err != nil {
+			b.Skip(err)
+		}
+		if err := json.Unmarshal(data[i], field); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return data, m
+}
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	data, _ := loadMiniFiles(b)
+	size := 0
+	for _, file := range data {
+		size += len(file)
+	}
+	b.SetBytes(int64(size))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, file := range data {
+			var ids map[string]int
+			if err := json.Unmarshal(file, &ids); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeBinary(b *testing.B) {
+	_, m := loadMiniFiles(b)
+	binary := Encode(m)
+	b.SetBytes(int64(len(binary)))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Decode(binary); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
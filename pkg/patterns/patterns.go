@@ -0,0 +1,74 @@
+// Package patterns looks up BUFF.163 pattern tiers, such as "Tier 1" for a
+// blue gem AK-47 | Case Hardened, from the seed lists of
+// buff163_grouped_ids/patterns.json.
+package patterns
+
+import (
+	"maps"
+	"slices"
+
+	"steamSkinIDs/pkg/itemname"
+)
+
+// Invert turns the seed lists of one skin into paint seed → tier. Tiers are
+// visited in name order, so a seed listed under several tiers always gets
+// the first of them.
+func Invert(seedsByTier map[string][]int) map[int]string {
+	tiers := make(map[int]string)
+
+	for _, tier := range slices.Sorted(maps.Keys(seedsByTier)) {
+		for _, seed := range seedsByTier[tier] {
+			if _, exists := tiers[seed]; !exists {
+				tiers[seed] = tier
+			}
+		}
+	}
+
+	return tiers
+}
+
+// Key finds the key patterns uses for an item, which keeps the star of
+// knives and gloves but drops StatTrak, Souvenir and the wear. The name can
+// be a skin name or any market_hash_name of the skin.
+func Key(patterns map[string]map[string][]int, name string) (string, bool) {
+	skin := itemname.SkinName(name)
+
+	for _, candidate := range []string{name, skin, "★ " + skin} {
+		if _, exists := patterns[candidate]; exists {
+			return candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// Tier returns the pattern tier of a paint seed, picking the same tier as
+// Invert when the seed is listed more than once.
+func Tier(patterns map[string]map[string][]int, name string, seed int) (string, bool) {
+	key, exists := Key(patterns, name)
+	if !exists {
+		return "", false
+	}
+
+	seedsByTier := patterns[key]
+	for _, tier := range slices.Sorted(maps.Keys(seedsByTier)) {
+		if slices.Contains(seedsByTier[tier], seed) {
+			return tier, true
+		}
+	}
+
+	return "", false
+}
+
+// TierSeeds returns the paint seeds of a pattern tier, sorted.
+func TierSeeds(patterns map[string]map[string][]int, name string, tier string) []int {
+	key, exists := Key(patterns, name)
+	if !exists {
+		return nil
+	}
+
+	seeds := slices.Clone(patterns[key][tier])
+	slices.Sort(seeds)
+
+	return seeds
+}
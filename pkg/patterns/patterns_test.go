@@ -0,0 +1,109 @@
+package patterns
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+var testPatterns = map[string]map[string][]int{
+	"AK-47 | Case Hardened": {
+		"Tier 1": {661, 670},
+		"Tier 2": {955, 661},
+	},
+	"★ Karambit | Case Hardened": {
+		"Tier 1": {387},
+	},
+}
+
+func TestInvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		seedsByTier map[string][]int
+		want        map[int]string
+	}{
+		{name: "empty", seedsByTier: nil, want: map[int]string{}},
+		{name: "disjoint tiers", seedsByTier: map[string][]int{"Tier 1": {1, 2}, "Tier 2": {3}}, want: map[int]string{1: "Tier 1", 2: "Tier 1", 3: "Tier 2"}},
+		{name: "overlap keeps the first tier", seedsByTier: map[string][]int{"Tier 2": {7}, "Tier 1": {7}}, want: map[int]string{7: "Tier 1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Map order varies between runs, so a nondeterministic
+			// inversion would fail some of the repetitions.
+			for range 20 {
+				if got := Invert(test.seedsByTier); !maps.Equal(got, test.want) {
+					t.Fatalf("Invert() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		want  string
+		found bool
+	}{
+		{"AK-47 | Case Hardened", "AK-47 | Case Hardened", true},
+		{"StatTrak™ AK-47 | Case Hardened (Field-Tested)", "AK-47 | Case Hardened", true},
+		{"★ Karambit | Case Hardened (Minimal Wear)", "★ Karambit | Case Hardened", true},
+		{"★ StatTrak™ Karambit | Case Hardened (Factory New)", "★ Karambit | Case Hardened", true},
+		{"Karambit | Case Hardened", "★ Karambit | Case Hardened", true},
+		{"AWP | Asiimov (Field-Tested)", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, found := Key(testPatterns, test.name)
+			if key != test.want || found != test.found {
+				t.Errorf("Key(%q) = %q, %t, want %q, %t", test.name, key, found, test.want, test.found)
+			}
+		})
+	}
+}
+
+func TestTier(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  int
+		want  string
+		found bool
+	}{
+		{"AK-47 | Case Hardened (Field-Tested)", 670, "Tier 1", true},
+		{"AK-47 | Case Hardened (Field-Tested)", 955, "Tier 2", true},
+		{"AK-47 | Case Hardened (Field-Tested)", 661, "Tier 1", true},
+		{"AK-47 | Case Hardened (Field-Tested)", 1, "", false},
+		{"AWP | Asiimov (Field-Tested)", 661, "", false},
+	}
+
+	for _, test := range tests {
+		tier, found := Tier(testPatterns, test.name, test.seed)
+		if tier != test.want || found != test.found {
+			t.Errorf("Tier(%q, %d) = %q, %t, want %q, %t", test.name, test.seed, tier, found, test.want, test.found)
+		}
+	}
+}
+
+func TestTierSeeds(t *testing.T) {
+	tests := []struct {
+		name string
+		tier string
+		want []int
+	}{
+		{"AK-47 | Case Hardened", "Tier 2", []int{661, 955}},
+		{"AK-47 | Case Hardened", "Tier 3", nil},
+		{"AWP | Asiimov", "Tier 1", nil},
+	}
+
+	for _, test := range tests {
+		if got := TierSeeds(testPatterns, test.name, test.tier); !slices.Equal(got, test.want) {
+			t.Errorf("TierSeeds(%q, %q) = %v, want %v", test.name, test.tier, got, test.want)
+		}
+	}
+
+	if !slices.Equal(testPatterns["AK-47 | Case Hardened"]["Tier 2"], []int{955, 661}) {
+		t.Error("TierSeeds() sorted the input seed list")
+	}
+}
@@ -0,0 +1,303 @@
+// Package search ranks market_hash_names against free-text queries with
+// wear, StatTrak, Souvenir and Doppler phase shorthand and small typos.
+package search
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"steamSkinIDs/pkg/itemname"
+)
+
+var wearAliases = map[string][]string{
+	"Factory New":    {"fn", "factorynew", "factory", "new"},
+	"Minimal Wear":   {"mw", "minimalwear", "minimal", "wear"},
+	"Field-Tested":   {"ft", "fieldtested", "field", "tested"},
+	"Well-Worn":      {"ww", "wellworn", "well", "worn"},
+	"Battle-Scarred": {"bs", "battlescarred", "battle", "scarred"},
+}
+
+var qualifierAliases = map[string][]string{
+	"stattrak": {"stattrak", "st"},
+	"souvenir": {"souvenir", "souv", "sv"},
+}
+
+// phaseAliases maps query shorthand to the phase names used by
+// buff163_grouped_ids/phases.json.
+var phaseAliases = map[string]string{
+	"p1": "Phase 1", "ph1": "Phase 1", "phase1": "Phase 1",
+	"p2": "Phase 2", "ph2": "Phase 2", "phase2": "Phase 2",
+	"p3": "Phase 3", "ph3": "Phase 3", "phase3": "Phase 3",
+	"p4": "Phase 4", "ph4": "Phase 4", "phase4": "Phase 4",
+	"ruby":       "Ruby",
+	"sapphire":   "Sapphire",
+	"saph":       "Sapphire",
+	"bp":         "Black Pearl",
+	"blackpearl": "Black Pearl",
+	"emerald":    "Emerald",
+	"em":         "Emerald",
+}
+
+// Result is a market_hash_name matching a query. Phase and Buff163PhaseID
+// are set when the query asked for a Doppler phase.
+type Result struct {
+	MarketHashName string  `json:"market_hash_name"`
+	Phase          string  `json:"phase,omitempty"`
+	Buff163PhaseID *int    `json:"buff163_phase_id,omitempty"`
+	Score          float64 `json:"score"`
+}
+
+// Index answers free-text queries like "karambit doppler p2 fn" over a set
+// of market_hash_names and their BUFF.163 phases.
+type Index struct {
+	documents []document
+	phases    map[string]map[string]int
+}
+
+type document struct {
+	name   string
+	tokens [][]string
+}
+
+// New indexes names and every name of phases, which maps a
+// market_hash_name to its BUFF.163 phase IDs like
+// buff163_grouped_ids/phases.json.
+func New(names []string, phases map[string]map[string]int) *Index {
+	unique := make(map[string]struct{}, len(names)+len(phases))
+	for _, name := range names {
+		unique[name] = struct{}{}
+	}
+	for name := range phases {
+		unique[name] = struct{}{}
+	}
+
+	index := &Index{
+		documents: make([]document, 0, len(unique)),
+		phases:    phases,
+	}
+	for _, name := range slices.Sorted(maps.Keys(unique)) {
+		index.documents = append(index.documents, document{name: name, tokens: documentTokens(name)})
+	}
+
+	return index
+}
+
+// normalizeWord lowercases a word and drops everything but letters and
+// digits, so "AK-47" becomes "ak47".
+func normalizeWord(word string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// documentTokens splits a name into tokens, each holding every spelling the
+// token can be matched by.
+func documentTokens(name string) [][]string {
+	var tokens [][]string
+
+	name, wear := itemname.SplitWear(name)
+	if wear != "" {
+		tokens = append(tokens, wearAliases[wear])
+	}
+
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == '|' || r == '(' || r == ')' }) {
+		normalized := normalizeWord(word)
+		if normalized == "" {
+			continue
+		}
+
+		if aliases, exists := qualifierAliases[normalized]; exists {
+			tokens = append(tokens, aliases)
+			continue
+		}
+
+		spellings := []string{normalized}
+		if parts := strings.FieldsFunc(strings.ToLower(word), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }); len(parts) > 1 {
+			spellings = append(spellings, parts...)
+		}
+		tokens = append(tokens, spellings)
+	}
+
+	return tokens
+}
+
+// matchScore rates how well a query word matches a token: exact matches
+// beat prefixes, which beat words one or two typos away.
+func matchScore(word string, spellings []string) float64 {
+	best := 0.0
+
+	for _, spelling := range spellings {
+		switch {
+
+		case word == spelling:
+			return 1
+
+		case len(word) >= 2 && strings.HasPrefix(spelling, word):
+			best = max(best, 0.75)
+
+		case len(word) >= 4 && editDistance(word, spelling, 2) <= 1:
+			best = max(best, 0.5)
+
+		case len(word) >= 8 && editDistance(word, spelling, 2) <= 2:
+			best = max(best, 0.4)
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, or limit+1
+// once it is known to exceed limit.
+func editDistance(a, b string, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			rowMin = min(rowMin, current[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func queryWords(query string) []string {
+	var words []string
+	for _, word := range strings.Fields(query) {
+		if normalized := normalizeWord(word); normalized != "" {
+			words = append(words, normalized)
+		}
+	}
+	return words
+}
+
+// extractPhase pulls a phase given as shorthand ("p2", "phase 2", "ruby",
+// "black pearl") out of the query words.
+func extractPhase(words []string) ([]string, string) {
+	phase := ""
+	remaining := make([]string, 0, len(words))
+
+	for i := 0; i < len(words); i++ {
+		if phase == "" && i+1 < len(words) {
+			if pair, exists := phaseAliases[words[i]+words[i+1]]; exists {
+				phase = pair
+				i++
+				continue
+			}
+		}
+		if phase == "" {
+			if alias, exists := phaseAliases[words[i]]; exists {
+				phase = alias
+				continue
+			}
+		}
+		remaining = append(remaining, words[i])
+	}
+
+	return remaining, phase
+}
+
+// Search returns up to limit results ranked by score. Every query word has
+// to match a word of the name, in any order; names with fewer unmatched
+// words rank higher. A phase in the query only matches names that BUFF.163
+// lists with that phase, falling back to a plain word search.
+func (index *Index) Search(query string, limit int) []Result {
+	words := queryWords(query)
+
+	if remaining, phase := extractPhase(words); phase != "" {
+		if results := index.search(remaining, phase, limit); len(results) > 0 {
+			return results
+		}
+	}
+
+	return index.search(words, "", limit)
+}
+
+func (index *Index) search(words []string, phase string, limit int) []Result {
+	if len(words) == 0 {
+		return nil
+	}
+
+	var results []Result
+
+	for _, document := range index.documents {
+		var phaseID *int
+		if phase != "" {
+			id, exists := index.phases[document.name][phase]
+			if !exists {
+				continue
+			}
+			phaseID = &id
+		}
+
+		matched := make([]bool, len(document.tokens))
+		total := 0.0
+
+		for _, word := range words {
+			best, bestToken := 0.0, -1
+			for i, spellings := range document.tokens {
+				if score := matchScore(word, spellings); score > best {
+					best, bestToken = score, i
+				}
+			}
+			if bestToken == -1 {
+				total = -1
+				break
+			}
+			matched[bestToken] = true
+			total += best
+		}
+		if total < 0 {
+			continue
+		}
+
+		coverage := 0
+		for _, isMatched := range matched {
+			if isMatched {
+				coverage++
+			}
+		}
+
+		results = append(results, Result{
+			MarketHashName: document.name,
+			Phase:          phase,
+			Buff163PhaseID: phaseID,
+			Score:          total/float64(len(words)) + float64(coverage)/float64(len(document.tokens)),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
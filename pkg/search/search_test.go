@@ -0,0 +1,104 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+var testNames = []string{
+	"AK-47 | Redline (Field-Tested)",
+	"AK-47 | Redline (Minimal Wear)",
+	"StatTrak™ AK-47 | Redline (Field-Tested)",
+	"AWP | Asiimov (Field-Tested)",
+	"★ Karambit | Doppler (Factory New)",
+	"★ Karambit | Doppler (Minimal Wear)",
+	"★ Karambit | Fade (Factory New)",
+	"Sticker | Crown (Foil)",
+}
+
+var testPhases = map[string]map[string]int{
+	"★ Karambit | Doppler (Factory New)":  {"Phase 2": 1002, "Ruby": 1005},
+	"★ Karambit | Doppler (Minimal Wear)": {"Phase 2": 2002},
+}
+
+func TestSearch(t *testing.T) {
+	index := New(testNames, testPhases)
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "ak redline ft", limit: 1, want: []string{"AK-47 | Redline (Field-Tested)"}},
+		{query: "ak47 redline field tested", limit: 1, want: []string{"AK-47 | Redline (Field-Tested)"}},
+		{query: "st ak redline ft", limit: 1, want: []string{"StatTrak™ AK-47 | Redline (Field-Tested)"}},
+		{query: "redline mw", limit: 10, want: []string{"AK-47 | Redline (Minimal Wear)"}},
+		{query: "asiimov", limit: 10, want: []string{"AWP | Asiimov (Field-Tested)"}},
+		{query: "asimov", limit: 10, want: []string{"AWP | Asiimov (Field-Tested)"}},
+		{query: "karambit fn", limit: 2, want: []string{"★ Karambit | Doppler (Factory New)", "★ Karambit | Fade (Factory New)"}},
+		{query: "karambit doppler ruby", limit: 10, want: []string{"★ Karambit | Doppler (Factory New)"}},
+		{query: "crown foil", limit: 10, want: []string{"Sticker | Crown (Foil)"}},
+		{query: "m4a4 howl", limit: 10, want: nil},
+		{query: "", limit: 10, want: nil},
+		{query: "redline", limit: 1, want: []string{"AK-47 | Redline (Field-Tested)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			var got []string
+			for _, result := range index.Search(test.query, test.limit) {
+				got = append(got, result.MarketHashName)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchPhase(t *testing.T) {
+	index := New(testNames, testPhases)
+
+	tests := []struct {
+		query string
+		name  string
+		phase string
+		id    int
+	}{
+		{query: "karambit doppler p2 fn", name: "★ Karambit | Doppler (Factory New)", phase: "Phase 2", id: 1002},
+		{query: "karambit doppler phase 2 mw", name: "★ Karambit | Doppler (Minimal Wear)", phase: "Phase 2", id: 2002},
+		{query: "karambit ruby", name: "★ Karambit | Doppler (Factory New)", phase: "Ruby", id: 1005},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			results := index.Search(test.query, 1)
+			if len(results) != 1 {
+				t.Fatalf("Search(%q) returned %d results, want 1", test.query, len(results))
+			}
+			result := results[0]
+			if result.MarketHashName != test.name || result.Phase != test.phase || result.Buff163PhaseID == nil || *result.Buff163PhaseID != test.id {
+				t.Errorf("Search(%q) = %+v, want %s %s %d", test.query, result, test.name, test.phase, test.id)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"asiimov", "asiimov", 2, 0},
+		{"asimov", "asiimov", 2, 1},
+		{"karambit", "karambti", 2, 2},
+		{"ak", "karambit", 2, 3},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b, test.limit); got != test.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"steamSkinIDs/pkg/search"
+)
+
+// newSearchIndex indexes the Steam market names and the BUFF.163 phases.
+func newSearchIndex(catalog *Catalog) *search.Index {
+	return search.New(sortedKeys(catalog.SteamMarketIDs), catalog.Buff163PhaseIDs)
+}
+
+func runSearch(args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	limit := flags.Int("limit", 10, "maximum number of results")
+	flags.Parse(args)
+
+	catalog, err := loadCatalog(*dir)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range newSearchIndex(catalog).Search(strings.Join(flags.Args(), " "), *limit) {
+		if result.Buff163PhaseID != nil {
+			fmt.Printf("%.2f  %s  [%s %d]\n", result.Score, result.MarketHashName, result.Phase, *result.Buff163PhaseID)
+		} else {
+			fmt.Printf("%.2f  %s\n", result.Score, result.MarketHashName)
+		}
+	}
+
+	return nil
+}
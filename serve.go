@@ -0,0 +1,423 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"steamSkinIDs/pkg/listings"
+	"steamSkinIDs/pkg/search"
+)
+
+const defaultSearchLimit = 20
+
+type server struct {
+	dir         string
+	fingerprint string
+	index       atomic.Pointer[catalogIndex]
+}
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	reload := flags.Duration("reload", 5*time.Second, "how often to check the files for changes")
+	flags.Parse(args)
+
+	s := &server{dir: *dir}
+	if err := s.reload(); err != nil {
+		return err
+	}
+
+	go s.watch(*reload)
+
+	fmt.Printf("Serving %s on %s\n", *dir, *addr)
+
+	return http.ListenAndServe(*addr, s.handler())
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{market_hash_name...}", s.handleItem)
+	mux.HandleFunc("GET /ids/{marketplace}/{id}", s.handleID)
+	mux.HandleFunc("GET /def/{index}", s.handleDefIndex)
+	mux.HandleFunc("GET /paint/{index}", s.handlePaintIndex)
+	mux.HandleFunc("GET /phase/{index}/{market_hash_name...}", s.handlePhase)
+	mux.HandleFunc("GET /patterns/{name...}", s.handlePattern)
+	mux.HandleFunc("GET /paintseed/{seed}/{market_hash_name...}", s.handlePaintseed)
+	mux.HandleFunc("GET /urls/{market_hash_name...}", s.handleURLs)
+	mux.HandleFunc("GET /graffiti/{sticker_kit_id}", s.handleGraffiti)
+	mux.HandleFunc("GET /stickers/{sticker_kit_id}", s.handleSticker)
+	mux.HandleFunc("GET /search", s.handleSearch)
+
+	return mux
+}
+
+// catalogFingerprint identifies the current version of the files in dir by
+// their sizes and modification times.
+func catalogFingerprint(dir string) (string, error) {
+	var builder strings.Builder
+
+	for _, file := range (&Catalog{}).files() {
+		info, err := os.Stat(filepath.Join(dir, file.path))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&builder, "%s:%d:%d;", file.path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return builder.String(), nil
+}
+
+func (s *server) reload() error {
+	fingerprint, err := catalogFingerprint(s.dir)
+	if err != nil {
+		return fmt.Errorf("Failed to stat catalog files: %w", err)
+	}
+
+	catalog, err := loadCatalog(s.dir)
+	if err != nil {
+		return err
+	}
+
+	s.index.Store(newCatalogIndex(catalog))
+	s.fingerprint = fingerprint
+
+	return nil
+}
+
+func (s *server) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reloaded, err := s.reloadIfChanged()
+		if err != nil {
+			fmt.Println("Failed to reload catalog. ", err)
+			continue
+		}
+		if reloaded {
+			fmt.Println("Reloaded catalog from", s.dir)
+		}
+	}
+}
+
+// reloadIfChanged reloads the catalog when the size or modification time of
+// one of its files changed since the last load.
+func (s *server) reloadIfChanged() (bool, error) {
+	fingerprint, err := catalogFingerprint(s.dir)
+	if err != nil || fingerprint == s.fingerprint {
+		return false, nil
+	}
+
+	if err := s.reload(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// writeJSON writes value with an ETag derived from the encoded body and
+// answers 304 Not Modified when the client already has it.
+func writeJSON(w http.ResponseWriter, r *http.Request, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func (s *server) handleItem(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("market_hash_name")
+
+	item := s.index.Load().item(name)
+	if item == nil {
+		writeError(w, http.StatusNotFound, "Unknown market_hash_name "+name)
+		return
+	}
+
+	writeJSON(w, r, item)
+}
+
+func (s *server) handleID(w http.ResponseWriter, r *http.Request) {
+	marketplace := r.PathValue("marketplace")
+	id := r.PathValue("id")
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		kind = "market"
+	}
+
+	items := s.index.Load().itemsByID(marketplace, kind, id)
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown %s %s ID %s", marketplace, kind, id))
+		return
+	}
+
+	writeJSON(w, r, items)
+}
+
+func (s *server) handleDefIndex(w http.ResponseWriter, r *http.Request) {
+	defIndex, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid def_index")
+		return
+	}
+
+	entries := s.index.Load().byDefIndex[defIndex]
+	if len(entries) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown def_index %d", defIndex))
+		return
+	}
+
+	writeJSON(w, r, entries)
+}
+
+func (s *server) handlePaintIndex(w http.ResponseWriter, r *http.Request) {
+	paintIndex, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid paint_index")
+		return
+	}
+
+	entries := s.index.Load().byPaintIndex[paintIndex]
+	if len(entries) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown paint_index %d", paintIndex))
+		return
+	}
+
+	writeJSON(w, r, entries)
+}
+
+func (s *server) handlePhase(w http.ResponseWriter, r *http.Request) {
+	paintIndex, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid paint_index")
+		return
+	}
+
+	name := r.PathValue("market_hash_name")
+
+	phase, id, found := s.index.Load().catalog.ResolvePhase(name, paintIndex)
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No phase with paint_index %d for %s", paintIndex, name))
+		return
+	}
+
+	response := struct {
+		MarketHashName string `json:"market_hash_name"`
+		PaintIndex     int    `json:"paint_index"`
+		Phase          string `json:"phase"`
+		Buff163PhaseID int    `json:"buff163_phase_id,omitempty"`
+	}{name, paintIndex, phase, id}
+
+	writeJSON(w, r, response)
+}
+
+func (s *server) handlePattern(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	catalog := s.index.Load().catalog
+
+	key, exists := catalog.patternName(name)
+	if !exists {
+		writeError(w, http.StatusNotFound, "No patterns for "+name)
+		return
+	}
+
+	if value := r.URL.Query().Get("seed"); value != "" {
+		seed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid seed")
+			return
+		}
+
+		tier, exists := catalog.PatternTier(key, seed)
+		if !exists {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Paint seed %d of %s has no tier", seed, key))
+			return
+		}
+
+		writeJSON(w, r, map[string]any{"name": key, "seed": seed, "tier": tier})
+		return
+	}
+
+	if tier := r.URL.Query().Get("tier"); tier != "" {
+		seeds := catalog.TierSeeds(key, tier)
+		if len(seeds) == 0 {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No paint seeds for %s %s", key, tier))
+			return
+		}
+
+		writeJSON(w, r, map[string]any{"name": key, "tier": tier, "seeds": seeds})
+		return
+	}
+
+	writeJSON(w, r, map[string]any{"name": key, "tiers": catalog.Buff163Patterns[key]})
+}
+
+func (s *server) handlePaintseed(w http.ResponseWriter, r *http.Request) {
+	seed, err := strconv.Atoi(r.PathValue("seed"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid seed")
+		return
+	}
+
+	name := r.PathValue("market_hash_name")
+
+	group, id, exists := s.index.Load().catalog.ResolvePaintseedGroup(name, seed)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Paint seed %d of %s has no BUFF.163 paintseed group", seed, name))
+		return
+	}
+
+	response := struct {
+		MarketHashName          string `json:"market_hash_name"`
+		Seed                    int    `json:"seed"`
+		PaintseedGroup          string `json:"paintseed_group"`
+		Buff163PaintseedGroupID int    `json:"buff163_paintseed_group_id"`
+	}{name, seed, group, id}
+
+	writeJSON(w, r, response)
+}
+
+func (s *server) handleURLs(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("market_hash_name")
+	query := r.URL.Query()
+
+	filter := listings.Filter{
+		Phase:          query.Get("phase"),
+		Tag:            query.Get("tag"),
+		PaintseedGroup: query.Get("paintseed_group"),
+	}
+
+	urls, err := s.index.Load().catalog.ListingURLs(name, filter)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, r, urls)
+}
+
+func (s *server) handleGraffiti(w http.ResponseWriter, r *http.Request) {
+	stickerKitID, err := strconv.Atoi(r.PathValue("sticker_kit_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid sticker_kit_id")
+		return
+	}
+
+	tintID := 0
+	if value := r.URL.Query().Get("tint"); value != "" {
+		tintID, err = strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid tint")
+			return
+		}
+	}
+
+	index := s.index.Load()
+
+	name, exists := index.resolveGraffiti(stickerKitID, tintID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown graffiti %d with tint %d", stickerKitID, tintID))
+		return
+	}
+
+	response := struct {
+		MarketHashName string `json:"market_hash_name"`
+		GraffitiID
+	}{name, index.catalog.SteamGraffiti[name]}
+
+	writeJSON(w, r, response)
+}
+
+func (s *server) handleSticker(w http.ResponseWriter, r *http.Request) {
+	stickerKitID, err := strconv.Atoi(r.PathValue("sticker_kit_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid sticker_kit_id")
+		return
+	}
+
+	name, sticker, exists := s.index.Load().stickerByKit(stickerKitID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown sticker kit %d", stickerKitID))
+		return
+	}
+
+	response := struct {
+		MarketHashName string `json:"market_hash_name"`
+		StickerMetadata
+	}{name, sticker}
+
+	writeJSON(w, r, response)
+}
+
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		writeError(w, http.StatusBadRequest, "Missing query parameter q")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	index := s.index.Load()
+	results := index.searchIndex.Search(query, limit)
+
+	type searchResponse struct {
+		search.Result
+		Item *ItemRecord `json:"item,omitempty"`
+	}
+
+	response := make([]searchResponse, 0, len(results))
+	for _, result := range results {
+		response = append(response, searchResponse{Result: result, Item: index.item(result.MarketHashName)})
+	}
+
+	writeJSON(w, r, response)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCatalogFiles writes the datasets of catalog to dir the way the
+// generator writes mini/.
+func writeCatalogFiles(t *testing.T, dir string, catalog *Catalog) {
+	t.Helper()
+
+	for _, file := range catalog.files() {
+		target := reflect.ValueOf(file.target).Elem()
+		if target.IsNil() {
+			continue
+		}
+
+		data, err := json.Marshal(target.Interface())
+		if err != nil {
+			t.Fatal(err)
+		}
+		filePath := filepath.Join(dir, file.path)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeCatalogFiles(t, dir, &Catalog{
+		SteamMarketIDs: map[string]int{
+			"AK-47 | Redline (Field-Tested)":           49,
+			"★ Karambit | Doppler (Factory New)":       1001,
+			"★ Bayonet | Case Hardened (Field-Tested)": 1002,
+		},
+		Buff163IDs:       map[string]int{"AK-47 | Redline (Field-Tested)": 33, "★ Karambit | Doppler (Factory New)": 43},
+		SteamStickerIDs:  map[string]int{"Sticker | Crown (Foil)": 76},
+		SteamGraffitiIDs: map[string]string{"Sealed Graffiti | Lambda (Battle Green)": "1711_7"},
+		StickerMetadata:  map[string]StickerMetadata{"Sticker | Crown (Foil)": {StickerKitID: 76, Effect: "Foil"}},
+		Buff163PhaseIDs: map[string]map[string]int{
+			"★ Karambit | Doppler (Factory New)": {"Phase 2": 1002},
+		},
+		Buff163PaintseedGroupIDs: map[string]map[string]int{
+			"★ Bayonet | Case Hardened (Field-Tested)": {"Blue Gem": 52},
+		},
+		Buff163Patterns: map[string]map[string][]int{
+			"★ Bayonet | Case Hardened": {"Blue Gem": {179, 555}},
+		},
+		DefIndexes:   map[string]int{"AK-47": 7},
+		PaintIndexes: map[string]int{"AK-47 | Redline": 282, "Karambit | Doppler Phase 2": 419},
+	})
+
+	s := &server{dir: dir}
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func get(t *testing.T, handler http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest("GET", target, nil)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestServeEndpoints(t *testing.T) {
+	handler := newTestServer(t).handler()
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		body   string
+	}{
+		{"item", "/items/" + url.PathEscape("AK-47 | Redline (Field-Tested)"), http.StatusOK, `"buff163":{"market":33}`},
+		{"unknown item", "/items/Unknown", http.StatusNotFound, "Unknown market_hash_name Unknown"},
+		{"market ID", "/ids/buff163/33", http.StatusOK, `"market_hash_name":"AK-47 | Redline (Field-Tested)"`},
+		{"ID of another kind", "/ids/steam/76?kind=stickers", http.StatusOK, `"market_hash_name":"Sticker | Crown (Foil)"`},
+		{"unknown ID", "/ids/steam/76", http.StatusNotFound, "Unknown steam market ID 76"},
+		{"def index", "/def/7", http.StatusOK, `[{"name":"AK-47","index":7}]`},
+		{"invalid def index", "/def/ak", http.StatusBadRequest, "Invalid def_index"},
+		{"unknown def index", "/def/1", http.StatusNotFound, "Unknown def_index 1"},
+		{"paint index", "/paint/282", http.StatusOK, `[{"name":"AK-47 | Redline","index":282}]`},
+		{"phase", "/phase/419/" + url.PathEscape("★ Karambit | Doppler (Factory New)"), http.StatusOK, `"phase":"Phase 2","buff163_phase_id":1002`},
+		{"unknown phase", "/phase/1/" + url.PathEscape("★ Karambit | Doppler (Factory New)"), http.StatusNotFound, "No phase with paint_index 1"},
+		{"pattern tiers", "/patterns/" + url.PathEscape("★ Bayonet | Case Hardened"), http.StatusOK, `"tiers":{"Blue Gem":[179,555]}`},
+		{"pattern tier of a seed", "/patterns/" + url.PathEscape("★ Bayonet | Case Hardened (Field-Tested)") + "?seed=179", http.StatusOK, `"tier":"Blue Gem"`},
+		{"seeds of a tier", "/patterns/" + url.PathEscape("★ Bayonet | Case Hardened") + "?tier=" + url.QueryEscape("Blue Gem"), http.StatusOK, `"seeds":[179,555]`},
+		{"invalid seed", "/patterns/" + url.PathEscape("★ Bayonet | Case Hardened") + "?seed=x", http.StatusBadRequest, "Invalid seed"},
+		{"paintseed group", "/paintseed/179/" + url.PathEscape("★ Bayonet | Case Hardened (Field-Tested)"), http.StatusOK, `"paintseed_group":"Blue Gem","buff163_paintseed_group_id":52`},
+		{"unlisted paint seed", "/paintseed/1/" + url.PathEscape("★ Bayonet | Case Hardened (Field-Tested)"), http.StatusNotFound, "has no BUFF.163 paintseed group"},
+		{"listing URLs", "/urls/" + url.PathEscape("AK-47 | Redline (Field-Tested)"), http.StatusOK, `"buff163":"https://buff.163.com/goods/33`},
+		{"graffiti", "/graffiti/1711?tint=7", http.StatusOK, `"market_hash_name":"Sealed Graffiti | Lambda (Battle Green)"`},
+		{"untinted graffiti", "/graffiti/1711", http.StatusNotFound, "Unknown graffiti 1711 with tint 0"},
+		{"sticker", "/stickers/76", http.StatusOK, `"market_hash_name":"Sticker | Crown (Foil)"`},
+		{"search", "/search?q=redline", http.StatusOK, `"market_hash_name":"AK-47 | Redline (Field-Tested)"`},
+		{"search without query", "/search", http.StatusBadRequest, "Missing query parameter q"},
+		{"search with invalid limit", "/search?q=redline&limit=0", http.StatusBadRequest, "Invalid limit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := get(t, handler, test.target, nil)
+			if response.Code != test.status {
+				t.Fatalf("status = %d, want %d, body %s", response.Code, test.status, response.Body)
+			}
+			if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("Content-Type = %q", contentType)
+			}
+			if !strings.Contains(response.Body.String(), test.body) {
+				t.Errorf("body = %s, want it to contain %s", response.Body, test.body)
+			}
+		})
+	}
+}
+
+func TestServeETag(t *testing.T) {
+	handler := newTestServer(t).handler()
+	target := "/items/" + url.PathEscape("AK-47 | Redline (Field-Tested)")
+
+	first := get(t, handler, target, nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d with ETag %q", first.Code, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"matching", etag, http.StatusNotModified},
+		{"weak", "W/" + etag, http.StatusNotModified},
+		{"one of several", `"other", ` + etag, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"stale", `"other"`, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := get(t, handler, target, http.Header{"If-None-Match": {test.ifNoneMatch}})
+			if response.Code != test.status {
+				t.Fatalf("status = %d, want %d", response.Code, test.status)
+			}
+			if response.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", response.Header().Get("ETag"), etag)
+			}
+			if test.status == http.StatusNotModified && response.Body.Len() > 0 {
+				t.Errorf("304 response has a body: %s", response.Body)
+			}
+		})
+	}
+}
+
+func TestServeReload(t *testing.T) {
+	s := newTestServer(t)
+	handler := s.handler()
+	filePath := filepath.Join(s.dir, "market_ids", "steam.json")
+
+	lookup := func() string {
+		response := get(t, handler, "/ids/steam/50", nil)
+		if response.Code != http.StatusOK {
+			return ""
+		}
+		var items []ItemRecord
+		if err := json.Unmarshal(response.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		return items[0].MarketHashName
+	}
+
+	if reloaded, err := s.reloadIfChanged(); reloaded || err != nil {
+		t.Fatalf("reloadIfChanged() = %t, %v without changes", reloaded, err)
+	}
+	if name := lookup(); name != "" {
+		t.Fatalf("steam ID 50 resolved to %q before it was added", name)
+	}
+
+	// A new entry changes the size of the file.
+	if err := os.WriteFile(filePath, []byte(`{"AK-47 | Redline (Field-Tested)":49,"AK-47 | Redline (Minimal Wear)":50}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := s.reloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("reloadIfChanged() = %t, %v after the size changed", reloaded, err)
+	}
+	if name := lookup(); name != "AK-47 | Redline (Minimal Wear)" {
+		t.Errorf("steam ID 50 resolved to %q after a reload", name)
+	}
+
+	// A changed ID keeps the size, only the modification time tells.
+	if err := os.WriteFile(filePath, []byte(`{"AK-47 | Redline (Field-Tested)":50,"AK-47 | Redline (Minimal Wear)":49}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filePath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := s.reloadIfChanged(); !reloaded || err != nil {
+		t.Fatalf("reloadIfChanged() = %t, %v after the modification time changed", reloaded, err)
+	}
+	if name := lookup(); name != "AK-47 | Redline (Field-Tested)" {
+		t.Errorf("steam ID 50 resolved to %q after a reload", name)
+	}
+
+	// A file that no longer decodes keeps the previous catalog.
+	if err := os.WriteFile(filePath, []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := s.reloadIfChanged(); reloaded || err == nil {
+		t.Fatalf("reloadIfChanged() = %t, %v for a broken file", reloaded, err)
+	}
+	if name := lookup(); name != "AK-47 | Redline (Field-Tested)" {
+		t.Errorf("steam ID 50 resolved to %q after a failed reload", name)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+const sourcesVersion = 1
+
+// SourceConfig ranks the sources that provide IDs for the same marketplace,
+// the first source that has an item wins, and limits the requests made to
+// every upstream host.
+type SourceConfig struct {
+	Version    int                  `json:"version"`
+	Precedence map[string][]string  `json:"precedence"`
+	Hosts      map[string]HostLimit `json:"hosts"`
+}
+
+// SourceConflict records the IDs the sources disagree on for one item and
+// the source whose ID was published.
+type SourceConflict struct {
+	Chosen string         `json:"chosen"`
+	IDs    map[string]int `json:"ids"`
+}
+
+func loadSources(filePath string) (*SourceConfig, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read sources %s: %w", filePath, err)
+	}
+
+	var config SourceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Failed to decode sources %s: %w", filePath, err)
+	}
+
+	if config.Version != sourcesVersion {
+		return nil, fmt.Errorf("Unsupported sources version %d in %s, expected %d", config.Version, filePath, sourcesVersion)
+	}
+
+	return &config, nil
+}
+
+// resolve merges the IDs of every source for a marketplace. Lower sources
+// that failed to fetch are nil and skipped. When the first source failed the
+// result is nil, so the previous file is kept instead of publishing a
+// degraded merge that silently prefers the lower sources.
+func (config *SourceConfig) resolve(marketplace string, sources map[string]map[string]int) (map[string]int, map[string]SourceConflict, error) {
+	precedence, exists := config.Precedence[marketplace]
+	if !exists {
+		return nil, nil, fmt.Errorf("No source precedence for %s", marketplace)
+	}
+
+	for _, source := range precedence {
+		if _, exists := sources[source]; !exists {
+			return nil, nil, fmt.Errorf("Unknown source %s in the precedence for %s", source, marketplace)
+		}
+	}
+	for _, source := range sortedKeys(sources) {
+		if !slices.Contains(precedence, source) {
+			return nil, nil, fmt.Errorf("Source %s is missing from the precedence for %s", source, marketplace)
+		}
+	}
+
+	conflicts := make(map[string]SourceConflict)
+	if sources[precedence[0]] == nil {
+		return nil, conflicts, nil
+	}
+
+	var resolved map[string]int
+
+	// Walk from the lowest to the highest precedence so that better
+	// sources overwrite worse ones.
+	for i := len(precedence) - 1; i >= 0; i-- {
+		source := precedence[i]
+		ids := sources[source]
+		if ids == nil {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make(map[string]int, len(ids))
+		}
+
+		for name, id := range ids {
+			previous, exists := resolved[name]
+			resolved[name] = id
+
+			if !exists {
+				continue
+			}
+
+			conflict, conflicting := conflicts[name]
+			if !conflicting && previous == id {
+				continue
+			}
+			if !conflicting {
+				conflict.IDs = make(map[string]int)
+				for _, other := range precedence[i+1:] {
+					if otherID, exists := sources[other][name]; exists {
+						conflict.IDs[other] = otherID
+					}
+				}
+			}
+			conflict.IDs[source] = id
+			conflict.Chosen = source
+			conflicts[name] = conflict
+		}
+	}
+
+	return resolved, conflicts, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveSources(t *testing.T) {
+	config := &SourceConfig{Version: sourcesVersion, Precedence: map[string][]string{
+		"buff163": {"ericzhu", "modestserhat"},
+	}}
+
+	tests := []struct {
+		name      string
+		sources   map[string]map[string]int
+		want      map[string]int
+		conflicts map[string]SourceConflict
+		wantErr   string
+	}{
+		{
+			name: "first source wins conflicts",
+			sources: map[string]map[string]int{
+				"ericzhu":      {"a": 1, "b": 2},
+				"modestserhat": {"a": 1, "b": 3, "c": 4},
+			},
+			want: map[string]int{"a": 1, "b": 2, "c": 4},
+			conflicts: map[string]SourceConflict{
+				"b": {Chosen: "ericzhu", IDs: map[string]int{"ericzhu": 2, "modestserhat": 3}},
+			},
+		},
+		{
+			name: "failed lower source is skipped",
+			sources: map[string]map[string]int{
+				"ericzhu":      {"a": 1},
+				"modestserhat": nil,
+			},
+			want:      map[string]int{"a": 1},
+			conflicts: map[string]SourceConflict{},
+		},
+		{
+			name: "failed first source keeps the previous file",
+			sources: map[string]map[string]int{
+				"ericzhu":      nil,
+				"modestserhat": {"a": 1},
+			},
+			want:      nil,
+			conflicts: map[string]SourceConflict{},
+		},
+		{
+			name: "source missing from the precedence",
+			sources: map[string]map[string]int{
+				"ericzhu":      {},
+				"modestserhat": {},
+				"other":        {},
+			},
+			wantErr: "Source other is missing",
+		},
+		{
+			name:    "source of the precedence not provided",
+			sources: map[string]map[string]int{"ericzhu": {}},
+			wantErr: "Unknown source modestserhat",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolved, conflicts, err := config.resolve("buff163", test.sources)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("resolve() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resolved, test.want) {
+				t.Errorf("resolve() = %v, want %v", resolved, test.want)
+			}
+			if !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Errorf("resolve() conflicts = %v, want %v", conflicts, test.conflicts)
+			}
+		})
+	}
+
+	if _, _, err := config.resolve("youpin898", nil); err == nil {
+		t.Error("resolve() of a marketplace without precedence succeeded")
+	}
+}
+
+func TestWithoutWeapons(t *testing.T) {
+	ids := map[string]int{"AK-47": 1, "AK-47 | Redline (Field-Tested)": 2}
+	original := maps.Clone(ids)
+
+	filtered := withoutWeapons(ids, map[string]int{"AK-47": 7})
+
+	if want := map[string]int{"AK-47 | Redline (Field-Tested)": 2}; !maps.Equal(filtered, want) {
+		t.Errorf("withoutWeapons() = %v, want %v", filtered, want)
+	}
+	if !maps.Equal(ids, original) {
+		t.Errorf("withoutWeapons() changed its input to %v", ids)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE items (
+	id               INTEGER PRIMARY KEY,
+	market_hash_name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE marketplace_ids (
+	item_id     INTEGER NOT NULL REFERENCES items (id),
+	marketplace TEXT    NOT NULL,
+	kind        TEXT    NOT NULL,
+	market_id           NOT NULL,
+	PRIMARY KEY (item_id, marketplace, kind)
+) WITHOUT ROWID;
+CREATE INDEX marketplace_ids_market_id ON marketplace_ids (marketplace, market_id);
+
+CREATE TABLE phases (
+	item_id     INTEGER NOT NULL REFERENCES items (id),
+	phase       TEXT    NOT NULL,
+	buff163_id  INTEGER NOT NULL,
+	paint_index INTEGER,
+	PRIMARY KEY (item_id, phase)
+) WITHOUT ROWID;
+CREATE INDEX phases_buff163_id ON phases (buff163_id);
+CREATE INDEX phases_paint_index ON phases (paint_index);
+
+CREATE TABLE tags (
+	item_id    INTEGER NOT NULL REFERENCES items (id),
+	tag        TEXT    NOT NULL,
+	buff163_id INTEGER NOT NULL,
+	PRIMARY KEY (item_id, tag)
+) WITHOUT ROWID;
+CREATE INDEX tags_buff163_id ON tags (buff163_id);
+
+CREATE TABLE paintseed_groups (
+	item_id         INTEGER NOT NULL REFERENCES items (id),
+	paintseed_group TEXT    NOT NULL,
+	buff163_id      INTEGER NOT NULL,
+	PRIMARY KEY (item_id, paintseed_group)
+) WITHOUT ROWID;
+CREATE INDEX paintseed_groups_buff163_id ON paintseed_groups (buff163_id);
+
+CREATE TABLE patterns (
+	item_id INTEGER NOT NULL REFERENCES items (id),
+	tier    TEXT    NOT NULL,
+	seed    INTEGER NOT NULL,
+	PRIMARY KEY (item_id, tier, seed)
+) WITHOUT ROWID;
+CREATE INDEX patterns_seed ON patterns (item_id, seed);
+
+CREATE TABLE def_indexes (
+	name      TEXT    NOT NULL PRIMARY KEY,
+	def_index INTEGER NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX def_indexes_def_index ON def_indexes (def_index);
+
+CREATE TABLE paint_indexes (
+	name        TEXT    NOT NULL PRIMARY KEY,
+	paint_index INTEGER NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX paint_indexes_paint_index ON paint_indexes (paint_index);
+`
+
+func saveSQLite(output *outputSet, catalog *Catalog, filePath string) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file for %s: %w", filePath, err)
+	}
+	tempPath := file.Name()
+	file.Close()
+
+	if err := os.Chmod(tempPath, 0o644); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to set permissions on file %s: %w", tempPath, err)
+	}
+
+	if err := writeSQLite(catalog, tempPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to write SQLite database %s: %w", filePath, err)
+	}
+
+	file, err = os.Open(tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to open file %s: %w", tempPath, err)
+	}
+	defer file.Close()
+
+	if err := file.Sync(); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("Failed to sync file %s: %w", tempPath, err)
+	}
+
+	output.stage(tempPath, filePath)
+
+	return nil
+}
+
+func writeSQLite(catalog *Catalog, path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("PRAGMA journal_mode = OFF; PRAGMA synchronous = OFF;"); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("Failed to create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	itemIDs := make(map[string]int)
+	insertItem, err := tx.Prepare("INSERT INTO items (id, market_hash_name) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	for i, name := range catalog.itemNames() {
+		itemIDs[name] = i + 1
+		if _, err := insertItem.Exec(i+1, name); err != nil {
+			return fmt.Errorf("Failed to insert item %s: %w", name, err)
+		}
+	}
+
+	insertID, err := tx.Prepare("INSERT INTO marketplace_ids (item_id, marketplace, kind, market_id) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, source := range catalog.idSources() {
+		for _, name := range sortedKeys(source.ids) {
+			if _, err := insertID.Exec(itemIDs[name], source.marketplace, source.kind, source.ids[name]); err != nil {
+				return fmt.Errorf("Failed to insert %s %s ID for %s: %w", source.marketplace, source.kind, name, err)
+			}
+		}
+	}
+
+	insertPhase, err := tx.Prepare("INSERT INTO phases (item_id, phase, buff163_id, paint_index) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(catalog.Buff163PhaseIDs) {
+		for _, phase := range sortedKeys(catalog.Buff163PhaseIDs[name]) {
+			var paintIndex any
+			if index, exists := catalog.PhasePaintIndex(name, phase); exists {
+				paintIndex = index
+			}
+			if _, err := insertPhase.Exec(itemIDs[name], phase, catalog.Buff163PhaseIDs[name][phase], paintIndex); err != nil {
+				return fmt.Errorf("Failed to insert %s for %s: %w", phase, name, err)
+			}
+		}
+	}
+
+	nestedTables := []struct {
+		query string
+		data  map[string]map[string]int
+	}{
+		{"INSERT INTO tags (item_id, tag, buff163_id) VALUES (?, ?, ?)", catalog.Buff163TagIDs},
+		{"INSERT INTO paintseed_groups (item_id, paintseed_group, buff163_id) VALUES (?, ?, ?)", catalog.Buff163PaintseedGroupIDs},
+	}
+	for _, table := range nestedTables {
+		insert, err := tx.Prepare(table.query)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(table.data) {
+			for _, subKey := range sortedKeys(table.data[name]) {
+				if _, err := insert.Exec(itemIDs[name], subKey, table.data[name][subKey]); err != nil {
+					return fmt.Errorf("Failed to insert %s for %s: %w", subKey, name, err)
+				}
+			}
+		}
+	}
+
+	insertPattern, err := tx.Prepare("INSERT OR IGNORE INTO patterns (item_id, tier, seed) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(catalog.Buff163Patterns) {
+		for _, tier := range sortedKeys(catalog.Buff163Patterns[name]) {
+			for _, seed := range catalog.Buff163Patterns[name][tier] {
+				if _, err := insertPattern.Exec(itemIDs[name], tier, seed); err != nil {
+					return fmt.Errorf("Failed to insert pattern %s for %s: %w", tier, name, err)
+				}
+			}
+		}
+	}
+
+	indexTables := []struct {
+		query string
+		data  map[string]int
+	}{
+		{"INSERT INTO def_indexes (name, def_index) VALUES (?, ?)", catalog.DefIndexes},
+		{"INSERT INTO paint_indexes (name, paint_index) VALUES (?, ?)", catalog.PaintIndexes},
+	}
+	for _, table := range indexTables {
+		insert, err := tx.Prepare(table.query)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(table.data) {
+			if _, err := insert.Exec(name, table.data[name]); err != nil {
+				return fmt.Errorf("Failed to insert index for %s: %w", name, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("Failed to vacuum database: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestWriteSQLite(t *testing.T) {
+	catalog := &Catalog{
+		SteamMarketIDs:  map[string]int{"AK-47 | Redline (Field-Tested)": 49, "★ Karambit | Doppler (Factory New)": 1001},
+		Buff163IDs:      map[string]int{"AK-47 | Redline (Field-Tested)": 33, "★ Karambit | Doppler (Factory New)": 43},
+		SteamStickerIDs: map[string]int{"Sticker | Crown (Foil)": 76},
+		Buff163PhaseIDs: map[string]map[string]int{
+			"★ Karambit | Doppler (Factory New)": {"Phase 2": 1002, "Ruby": 1005},
+		},
+		Buff163Patterns: map[string]map[string][]int{
+			"AK-47 | Case Hardened": {"Tier 1": {661, 955}},
+		},
+		DefIndexes:   map[string]int{"AK-47": 7, "Karambit": 507},
+		PaintIndexes: map[string]int{"AK-47 | Redline": 282, "Karambit | Doppler Phase 2": 419},
+	}
+	catalog.Phases = buildPhases(catalog.PaintIndexes, catalog.Buff163PhaseIDs)
+
+	filePath := filepath.Join(t.TempDir(), "catalog.sqlite")
+	if err := writeSQLite(catalog, filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		args  []any
+		want  [][]any
+	}{
+		{
+			name: "marketplace IDs of an item",
+			query: `SELECT m.marketplace, m.kind, m.market_id FROM items i
+				JOIN marketplace_ids m ON m.item_id = i.id
+				WHERE i.market_hash_name = ? ORDER BY m.marketplace`,
+			args: []any{"AK-47 | Redline (Field-Tested)"},
+			want: [][]any{{"buff163", "market", int64(33)}, {"steam", "market", int64(49)}},
+		},
+		{
+			name: "item of a marketplace ID",
+			query: `SELECT i.market_hash_name FROM marketplace_ids m
+				JOIN items i ON i.id = m.item_id
+				WHERE m.marketplace = ? AND m.market_id = ?`,
+			args: []any{"steam", 76},
+			want: [][]any{{"Sticker | Crown (Foil)"}},
+		},
+		{
+			name: "phases with and without paint index",
+			query: `SELECT p.phase, p.buff163_id, p.paint_index FROM items i
+				JOIN phases p ON p.item_id = i.id
+				WHERE i.market_hash_name = ? ORDER BY p.phase`,
+			args: []any{"★ Karambit | Doppler (Factory New)"},
+			want: [][]any{{"Phase 2", int64(1002), int64(419)}, {"Ruby", int64(1005), nil}},
+		},
+		{
+			name: "pattern tier of a seed",
+			query: `SELECT i.market_hash_name, p.tier FROM patterns p
+				JOIN items i ON i.id = p.item_id
+				WHERE p.seed = ?`,
+			args: []any{955},
+			want: [][]any{{"AK-47 | Case Hardened", "Tier 1"}},
+		},
+		{
+			name:  "def index",
+			query: `SELECT name FROM def_indexes WHERE def_index = ?`,
+			args:  []any{507},
+			want:  [][]any{{"Karambit"}},
+		},
+		{
+			name:  "paint index",
+			query: `SELECT name FROM paint_indexes WHERE paint_index = ?`,
+			args:  []any{282},
+			want:  [][]any{{"AK-47 | Redline"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := queryRows(t, db, test.query, test.args...); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	var indexes []string
+	for _, row := range queryRows(t, db, `SELECT name FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL`) {
+		indexes = append(indexes, row[0].(string))
+	}
+	for _, index := range []string{
+		"marketplace_ids_market_id",
+		"phases_buff163_id",
+		"phases_paint_index",
+		"tags_buff163_id",
+		"paintseed_groups_buff163_id",
+		"patterns_seed",
+		"def_indexes_def_index",
+		"paint_indexes_paint_index",
+	} {
+		if !slices.Contains(indexes, index) {
+			t.Errorf("index %s is missing, have %q", index, indexes)
+		}
+	}
+}
+
+func queryRows(t *testing.T, db *sql.DB, query string, args ...any) [][]any {
+	t.Helper()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result [][]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, values)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errStageSkipped = errors.New("prerequisite did not complete")
+
+// stage is one step of a run. It starts as soon as every stage it depends on
+// has succeeded and is skipped when any of them failed or was skipped, so a
+// stage never runs on the zero value of data it needs.
+type stage struct {
+	name string
+	deps []string
+	run  func(ctx context.Context) error
+}
+
+// runStages runs the stages concurrently in dependency order, each bounded by
+// timeout, and returns the error of every stage that failed or was skipped.
+// Skipped stages wrap errStageSkipped, stages that had not started when ctx
+// was cancelled are skipped as well.
+func runStages(ctx context.Context, stages []stage, timeout time.Duration) (map[string]error, error) {
+	byName := make(map[string]*stage, len(stages))
+	for i := range stages {
+		s := &stages[i]
+		if _, exists := byName[s.name]; exists {
+			return nil, fmt.Errorf("Duplicate stage %s", s.name)
+		}
+		byName[s.name] = s
+	}
+
+	for _, s := range stages {
+		for _, dep := range s.deps {
+			if _, exists := byName[dep]; !exists {
+				return nil, fmt.Errorf("Stage %s depends on unknown stage %s", s.name, dep)
+			}
+		}
+	}
+
+	if cycle := findStageCycle(stages, byName); cycle != "" {
+		return nil, fmt.Errorf("Stage %s depends on itself through a cycle", cycle)
+	}
+
+	done := make(map[string]chan struct{}, len(stages))
+	for _, s := range stages {
+		done[s.name] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	errs := make(map[string]error)
+
+	var wg sync.WaitGroup
+	wg.Add(len(stages))
+
+	for _, s := range stages {
+		go func() {
+			defer wg.Done()
+			defer close(done[s.name])
+
+			for _, dep := range s.deps {
+				<-done[dep]
+			}
+
+			mu.Lock()
+			var failed string
+			for _, dep := range s.deps {
+				if errs[dep] != nil {
+					failed = dep
+					break
+				}
+			}
+			if failed != "" {
+				errs[s.name] = fmt.Errorf("Skipped %s, %s did not complete: %w", s.name, failed, errStageSkipped)
+			} else if err := ctx.Err(); err != nil {
+				errs[s.name] = fmt.Errorf("Skipped %s, %w: %w", s.name, err, errStageSkipped)
+				failed = "context"
+			}
+			mu.Unlock()
+
+			if failed != "" {
+				return
+			}
+
+			stageCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := s.run(stageCtx); err != nil {
+				mu.Lock()
+				errs[s.name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errs, nil
+}
+
+// findStageCycle returns the name of a stage on a dependency cycle, or an
+// empty string when the stages form a DAG.
+func findStageCycle(stages []stage, byName map[string]*stage) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(stages))
+
+	var visit func(name string) string
+	visit = func(name string) string {
+		switch state[name] {
+		case visiting:
+			return name
+		case visited:
+			return ""
+		}
+
+		state[name] = visiting
+		for _, dep := range byName[name].deps {
+			if cycle := visit(dep); cycle != "" {
+				return cycle
+			}
+		}
+		state[name] = visited
+
+		return ""
+	}
+
+	for _, s := range stages {
+		if cycle := visit(s.name); cycle != "" {
+			return cycle
+		}
+	}
+
+	return ""
+}
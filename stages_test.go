@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunStages(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+
+	succeed := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errFetch }
+
+	tests := []struct {
+		name    string
+		stages  []stage
+		failed  []string
+		skipped []string
+		wantErr string
+	}{
+		{
+			name: "independent stages",
+			stages: []stage{
+				{name: "a", run: succeed},
+				{name: "b", run: succeed},
+			},
+		},
+		{
+			name: "failure skips dependents transitively",
+			stages: []stage{
+				{name: "skins", run: fail},
+				{name: "market", deps: []string{"skins"}, run: succeed},
+				{name: "merge", deps: []string{"market"}, run: succeed},
+				{name: "agents", run: succeed},
+			},
+			failed:  []string{"skins"},
+			skipped: []string{"market", "merge"},
+		},
+		{
+			name: "one failed dependency is enough to skip",
+			stages: []stage{
+				{name: "a", run: succeed},
+				{name: "b", run: fail},
+				{name: "c", deps: []string{"a", "b"}, run: succeed},
+			},
+			failed:  []string{"b"},
+			skipped: []string{"c"},
+		},
+		{
+			name:    "duplicate stage",
+			stages:  []stage{{name: "a", run: succeed}, {name: "a", run: succeed}},
+			wantErr: "Duplicate stage a",
+		},
+		{
+			name:    "unknown dependency",
+			stages:  []stage{{name: "a", deps: []string{"b"}, run: succeed}},
+			wantErr: "depends on unknown stage b",
+		},
+		{
+			name: "cycle",
+			stages: []stage{
+				{name: "a", deps: []string{"c"}, run: succeed},
+				{name: "b", deps: []string{"a"}, run: succeed},
+				{name: "c", deps: []string{"b"}, run: succeed},
+			},
+			wantErr: "through a cycle",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs, err := runStages(context.Background(), test.stages, time.Second)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("runStages() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var failed, skipped []string
+			for _, name := range sortedKeys(errs) {
+				if errors.Is(errs[name], errStageSkipped) {
+					skipped = append(skipped, name)
+				} else {
+					failed = append(failed, name)
+				}
+			}
+			if !slices.Equal(failed, test.failed) {
+				t.Errorf("failed stages = %q, want %q", failed, test.failed)
+			}
+			if !slices.Equal(skipped, test.skipped) {
+				t.Errorf("skipped stages = %q, want %q", skipped, test.skipped)
+			}
+		})
+	}
+}
+
+func TestRunStagesOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	stages := []stage{
+		{name: "merge", deps: []string{"market", "skins"}, run: record("merge")},
+		{name: "market", deps: []string{"skins"}, run: record("market")},
+		{name: "skins", run: record("skins")},
+	}
+
+	errs, err := runStages(context.Background(), stages, time.Second)
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("runStages() = %v, %v", errs, err)
+	}
+	if want := []string{"skins", "market", "merge"}; !slices.Equal(order, want) {
+		t.Errorf("stages ran in order %q, want %q", order, want)
+	}
+}
+
+func TestRunStagesTimeout(t *testing.T) {
+	stages := []stage{
+		{name: "slow", run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{name: "after", deps: []string{"slow"}, run: func(ctx context.Context) error { return nil }},
+	}
+
+	errs, err := runStages(context.Background(), stages, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(errs["slow"], context.DeadlineExceeded) {
+		t.Errorf("slow stage error = %v, want the stage timeout", errs["slow"])
+	}
+	if !errors.Is(errs["after"], errStageSkipped) {
+		t.Errorf("dependent stage error = %v, want it skipped", errs["after"])
+	}
+}
+
+func TestRunStagesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ran := false
+	stages := []stage{{name: "a", run: func(ctx context.Context) error { ran = true; return nil }}}
+
+	errs, err := runStages(ctx, stages, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ran || !errors.Is(errs["a"], errStageSkipped) || !errors.Is(errs["a"], context.Canceled) {
+		t.Errorf("stage ran = %t with error %v, want it skipped as cancelled", ran, errs["a"])
+	}
+}
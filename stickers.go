@@ -0,0 +1,15 @@
+package main
+
+// stickerNames indexes sticker_metadata by sticker kit ID. When two stickers
+// share a kit, the first name in sort order wins.
+func stickerNames(stickers map[string]StickerMetadata) map[int]string {
+	names := make(map[int]string, len(stickers))
+
+	for _, name := range sortedKeys(stickers) {
+		if _, exists := names[stickers[name].StickerKitID]; !exists {
+			names[stickers[name].StickerKitID] = name
+		}
+	}
+
+	return names
+}
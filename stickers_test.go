@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestStickerByKit(t *testing.T) {
+	index := newCatalogIndex(&Catalog{StickerMetadata: map[string]StickerMetadata{
+		"Sticker | Crown (Foil)":             {StickerKitID: 76, Effect: "Foil"},
+		"Sticker | Howling Dawn":             {StickerKitID: 75},
+		"Sticker | Howling Dawn (Duplicate)": {StickerKitID: 75},
+	}})
+
+	tests := []struct {
+		name         string
+		stickerKitID int
+		want         string
+		effect       string
+		found        bool
+	}{
+		{"known kit", 76, "Sticker | Crown (Foil)", "Foil", true},
+		{"duplicate keeps the first name", 75, "Sticker | Howling Dawn", "", true},
+		{"unknown kit", 1, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, sticker, found := index.stickerByKit(test.stickerKitID)
+			if name != test.want || sticker.Effect != test.effect || found != test.found {
+				t.Errorf("stickerByKit(%d) = %q, %+v, %t, want %q, %t", test.stickerKitID, name, sticker, found, test.want, test.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// streamArray decodes a JSON array one element at a time, so only a single
+// element is held in memory. T should declare just the fields the caller
+// needs, the decoder skips all others without materializing them.
+func streamArray[T any](r io.Reader, element func(item *T) error) error {
+	decoder := json.NewDecoder(r)
+
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+		if err := element(&item); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("Expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
+// skinFields is the part of a ByMykel skin that the generator uses.
+type skinFields struct {
+	Weapon struct {
+		WeaponID int    `json:"weapon_id"`
+		Name     string `json:"name"`
+	} `json:"weapon"`
+	Pattern *struct {
+		Name string `json:"name"`
+	} `json:"pattern"`
+	PaintIndex *string `json:"paint_index"`
+	Phase      *string `json:"phase"`
+}
+
+// stickerFields is the part of a ByMykel sticker that the generator uses.
+type stickerFields struct {
+	DefIndex string `json:"def_index"`
+	Crates   []struct {
+		Name string `json:"name"`
+	} `json:"crates"`
+	TournamentEvent  string  `json:"tournament_event"`
+	Type             string  `json:"type"`
+	MarketHashName   *string `json:"market_hash_name"`
+	Effect           string  `json:"effect"`
+	TournamentTeam   string  `json:"tournament_team"`
+	TournamentPlayer string  `json:"tournament_player"`
+}
+
+// decodeSteamIndexes streams the ByMykel skins.json.
+func decodeSteamIndexes(r io.Reader) (map[string]int, map[string]int, error) {
+	defIndexes := make(map[string]int)
+	paintIndexes := make(map[string]int)
+
+	err := streamArray(r, func(item *skinFields) error {
+		defName := item.Weapon.Name
+
+		defIndexes[defName] = item.Weapon.WeaponID
+
+		if item.PaintIndex == nil || item.Pattern == nil {
+			return nil
+		}
+
+		paintIndex, err := strconv.Atoi(*item.PaintIndex)
+		if err != nil {
+			return nil
+		}
+
+		paint := item.Pattern.Name
+
+		var baseKeyBuilder strings.Builder
+		baseKeyBuilder.Grow(len(defName) + 3 + len(paint))
+		baseKeyBuilder.WriteString(defName)
+		baseKeyBuilder.WriteString(" | ")
+		baseKeyBuilder.WriteString(paint)
+		baseKey := baseKeyBuilder.String()
+
+		if item.Phase != nil {
+			var keyBuilder strings.Builder
+			keyBuilder.Grow(len(baseKey) + 1 + len(*item.Phase))
+			keyBuilder.WriteString(baseKey)
+			keyBuilder.WriteByte(' ')
+			keyBuilder.WriteString(*item.Phase)
+			phaseKey := keyBuilder.String()
+
+			paintIndexes[phaseKey] = paintIndex
+		} else {
+			paintIndexes[baseKey] = paintIndex
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return defIndexes, paintIndexes, nil
+}
+
+// decodeSteamStickers streams the ByMykel stickers.json.
+func decodeSteamStickers(r io.Reader) (map[string]int, map[string]StickerMetadata, error) {
+	ids := make(map[string]int)
+	metadata := make(map[string]StickerMetadata)
+
+	err := streamArray(r, func(item *stickerFields) error {
+		id, err := strconv.Atoi(item.DefIndex)
+		if err != nil || item.MarketHashName == nil {
+			return nil
+		}
+
+		ids[*item.MarketHashName] = id
+
+		crates := make([]string, 0, len(item.Crates))
+		for _, crate := range item.Crates {
+			crates = append(crates, crate.Name)
+		}
+		sort.Strings(crates)
+
+		metadata[*item.MarketHashName] = StickerMetadata{
+			StickerKitID:     id,
+			Type:             item.Type,
+			Effect:           item.Effect,
+			TournamentEvent:  item.TournamentEvent,
+			TournamentTeam:   item.TournamentTeam,
+			TournamentPlayer: item.TournamentPlayer,
+			Crates:           crates,
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, metadata, nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecodeSteamIndexes(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		defIndexes   map[string]int
+		paintIndexes map[string]int
+		wantErr      bool
+	}{
+		{
+			name: "skins with and without phases",
+			payload: `[
+				{"id": "skin-1", "weapon": {"weapon_id": 7, "name": "AK-47"}, "pattern": {"name": "Redline"}, "paint_index": "282", "wears": [{"name": "Field-Tested"}]},
+				{"weapon": {"weapon_id": 507, "name": "★ Karambit"}, "pattern": {"name": "Doppler"}, "paint_index": "418", "phase": "Phase 1"},
+				{"weapon": {"weapon_id": 507, "name": "★ Karambit"}, "pattern": null, "paint_index": null},
+				{"weapon": {"weapon_id": 7, "name": "AK-47"}, "pattern": {"name": "Broken"}, "paint_index": "n/a"}
+			]`,
+			defIndexes:   map[string]int{"AK-47": 7, "★ Karambit": 507},
+			paintIndexes: map[string]int{"AK-47 | Redline": 282, "★ Karambit | Doppler Phase 1": 418},
+		},
+		{name: "empty array", payload: `[]`, defIndexes: map[string]int{}, paintIndexes: map[string]int{}},
+		{name: "not an array", payload: `{}`, wantErr: true},
+		{name: "truncated", payload: `[{"weapon": {"weapon_id": 7,`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defIndexes, paintIndexes, err := decodeSteamIndexes(strings.NewReader(test.payload))
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("decodeSteamIndexes() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(defIndexes, test.defIndexes) {
+				t.Errorf("def indexes = %v, want %v", defIndexes, test.defIndexes)
+			}
+			if !reflect.DeepEqual(paintIndexes, test.paintIndexes) {
+				t.Errorf("paint indexes = %v, want %v", paintIndexes, test.paintIndexes)
+			}
+		})
+	}
+}
+
+func TestDecodeSteamStickers(t *testing.T) {
+	payload := `[
+		{"def_index": "76", "market_hash_name": "Sticker | Crown (Foil)", "type": "Other", "effect": "Foil", "crates": [{"name": "B"}, {"name": "A"}], "image": "x"},
+		{"def_index": "77", "market_hash_name": null},
+		{"def_index": "kit", "market_hash_name": "Sticker | Broken"}
+	]`
+
+	ids, metadata, err := decodeSteamStickers(strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]int{"Sticker | Crown (Foil)": 76}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	want := map[string]StickerMetadata{
+		"Sticker | Crown (Foil)": {StickerKitID: 76, Type: "Other", Effect: "Foil", Crates: []string{"A", "B"}},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("metadata = %+v, want %+v", metadata, want)
+	}
+}
+
+// syntheticPayload builds a JSON array of count items shaped like the
+// ByMykel files, including the fields the streaming decoder skips.
+func syntheticPayload(count int, item func(i int) map[string]any) []byte {
+	items := make([]map[string]any, count)
+	for i := range items {
+		items[i] = item(i)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func syntheticSkin(i int) map[string]any {
+	return map[string]any{
+		"id":          fmt.Sprintf("skin-%d", i),
+		"name":        fmt.Sprintf("AK-47 | Skin %d", i),
+		"description": strings.Repeat("A rifle finish with a long description. ", 8),
+		"weapon":      map[string]any{"id": "weapon_ak47", "weapon_id": 7, "name": "AK-47"},
+		"category":    map[string]any{"id": "sfui_invpanel_filter_rifle", "name": "Rifles"},
+		"pattern":     map[string]any{"id": fmt.Sprintf("pattern-%d", i), "name": fmt.Sprintf("Skin %d", i)},
+		"min_float":   0.0,
+		"max_float":   1.0,
+		"rarity":      map[string]any{"id": "rarity_rare_weapon", "name": "Mil-Spec Grade", "color": "#4b69ff"},
+		"stattrak":    true,
+		"souvenir":    false,
+		"paint_index": fmt.Sprint(i),
+		"wears":       []map[string]any{{"id": "SFUI_InvTooltip_Wear_Amount_0", "name": "Factory New"}, {"id": "SFUI_InvTooltip_Wear_Amount_1", "name": "Minimal Wear"}},
+		"collections": []map[string]any{{"id": "collection-1", "name": "The Collection", "image": "https://example.com/collection.png"}},
+		"crates":      []map[string]any{{"id": "crate-1", "name": "Weapon Case", "image": "https://example.com/crate.png"}},
+		"team":        map[string]any{"id": "both", "name": "Both Teams"},
+		"image":       "https://example.com/skin.png",
+	}
+}
+
+func syntheticSticker(i int) map[string]any {
+	return map[string]any{
+		"id":               fmt.Sprintf("sticker-%d", i),
+		"name":             fmt.Sprintf("Sticker | Player %d", i),
+		"description":      strings.Repeat("A sticker with a long description. ", 4),
+		"def_index":        fmt.Sprint(i),
+		"rarity":           map[string]any{"id": "rarity_rare", "name": "High Grade", "color": "#4b69ff"},
+		"crates":           []map[string]any{{"id": "crate-1", "name": "Sticker Capsule", "image": "https://example.com/crate.png"}},
+		"tournament_event": "Major",
+		"type":             "Player",
+		"market_hash_name": fmt.Sprintf("Sticker | Player %d", i),
+		"effect":           "Holo",
+		"image":            "https://example.com/sticker.png",
+	}
+}
+
+// Skin and Sticker are the full ByMykel items the generator decoded before it
+// streamed them. They are kept as the baseline of the benchmarks.
+type Skin struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Weapon      struct {
+		ID       string `json:"id"`
+		WeaponID int    `json:"weapon_id"`
+		Name     string `json:"name"`
+	} `json:"weapon"`
+	Category struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"category"`
+	Pattern *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"pattern"`
+	MinFloat *float64 `json:"min_float"`
+	MaxFloat *float64 `json:"max_float"`
+	Rarity   struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"rarity"`
+	Stattrak   bool    `json:"stattrak"`
+	Souvenir   bool    `json:"souvenir"`
+	PaintIndex *string `json:"paint_index"`
+	Wears      []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"wears"`
+	Collections []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Image string `json:"image"`
+	} `json:"collections"`
+	Crates []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Image string `json:"image"`
+	} `json:"crates"`
+	Team struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"team"`
+	LegacyModel  bool    `json:"legacy_model"`
+	Image        string  `json:"image"`
+	Phase        *string `json:"phase"`
+	SpecialNotes []struct {
+		Source string `json:"source"`
+		Text   string `json:"text"`
+	} `json:"special_notes"`
+}
+
+type Sticker struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DefIndex    string `json:"def_index"`
+	Rarity      struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	} `json:"rarity"`
+	Crates []struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Image string `json:"image"`
+	} `json:"crates"`
+	TournamentEvent  string  `json:"tournament_event"`
+	Type             string  `json:"type"`
+	MarketHashName   *string `json:"market_hash_name"`
+	Effect           string  `json:"effect"`
+	Image            string  `json:"image"`
+	TournamentTeam   string  `json:"tournament_team"`
+	TournamentPlayer string  `json:"tournament_player"`
+	SpecialNotes     []struct {
+		Source string `json:"source"`
+		Text   string `json:"text"`
+	} `json:"special_notes"`
+}
+
+// decodeSkinStructs is the decode path decodeSteamIndexes replaced: the
+// whole array is decoded into Skin before the indexes are built.
+func decodeSkinStructs(r io.Reader) (map[string]int, map[string]int, error) {
+	var data []Skin
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, nil, err
+	}
+
+	defIndexes := make(map[string]int, len(data))
+	paintIndexes := make(map[string]int, len(data))
+
+	for _, item := range data {
+		defIndexes[item.Weapon.Name] = item.Weapon.WeaponID
+
+		if item.PaintIndex == nil || item.Pattern == nil {
+			continue
+		}
+		paintIndex, err := strconv.Atoi(*item.PaintIndex)
+		if err != nil {
+			continue
+		}
+
+		key := item.Weapon.Name + " | " + item.Pattern.Name
+		if item.Phase != nil {
+			key += " " + *item.Phase
+		}
+		paintIndexes[key] = paintIndex
+	}
+
+	return defIndexes, paintIndexes, nil
+}
+
+// decodeStickerStructs is the decode path decodeSteamStickers replaced.
+func decodeStickerStructs(r io.Reader) (map[string]int, error) {
+	var data []Sticker
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int, len(data))
+	for _, item := range data {
+		id, err := strconv.Atoi(item.DefIndex)
+		if err == nil && item.MarketHashName != nil {
+			ids[*item.MarketHashName] = id
+		}
+	}
+
+	return ids, nil
+}
+
+// The struct decoders have to produce the same datasets as the streaming
+// ones for the benchmarks to compare like with like.
+func TestStructDecodersMatchStream(t *testing.T) {
+	skins := syntheticPayload(100, syntheticSkin)
+	structDefs, structPaints, err := decodeSkinStructs(bytes.NewReader(skins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamDefs, streamPaints, err := decodeSteamIndexes(bytes.NewReader(skins))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(structDefs, streamDefs) || !reflect.DeepEqual(structPaints, streamPaints) {
+		t.Error("skin struct and stream decoders disagree")
+	}
+
+	stickers := syntheticPayload(100, syntheticSticker)
+	structIDs, err := decodeStickerStructs(bytes.NewReader(stickers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamIDs, _, err := decodeSteamStickers(bytes.NewReader(stickers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(structIDs, streamIDs) {
+		t.Error("sticker struct and stream decoders disagree")
+	}
+}
+
+func BenchmarkSkinsStructs(b *testing.B) {
+	data := syntheticPayload(20000, syntheticSkin)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, _, err := decodeSkinStructs(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSkinsStream(b *testing.B) {
+	data := syntheticPayload(20000, syntheticSkin)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, _, err := decodeSteamIndexes(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStickersStructs(b *testing.B) {
+	data := syntheticPayload(20000, syntheticSticker)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, err := decodeStickerStructs(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStickersStream(b *testing.B) {
+	data := syntheticPayload(20000, syntheticSticker)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if _, _, err := decodeSteamStickers(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
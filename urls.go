@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"steamSkinIDs/pkg/listings"
+)
+
+// ListingURLs returns the listing URL of an item on every marketplace that
+// has it, keyed like market_ids.
+func (c *Catalog) ListingURLs(marketHashName string, filter listings.Filter) (map[string]string, error) {
+	return listings.URLs(listings.Item{
+		MarketHashName:           marketHashName,
+		MarketIDs:                c.marketIDs(marketHashName),
+		Buff163PhaseIDs:          c.Buff163PhaseIDs[marketHashName],
+		Buff163TagIDs:            c.Buff163TagIDs[marketHashName],
+		Buff163PaintseedGroupIDs: c.Buff163PaintseedGroupIDs[marketHashName],
+	}, filter)
+}
+
+func runURL(args []string) error {
+	flags := flag.NewFlagSet("url", flag.ExitOnError)
+	dir := flags.String("dir", "./mini", "directory with the generated mini files")
+	phase := flags.String("phase", "", "Doppler phase, e.g. \"Phase 2\" or \"Ruby\"")
+	tag := flags.String("tag", "", "BUFF.163 tag")
+	paintseedGroup := flags.String("paintseed-group", "", "BUFF.163 paintseed group, e.g. \"Blue Gem\"")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: url [-phase <phase>] [-tag <tag>] [-paintseed-group <group>] <market_hash_name>")
+	}
+
+	catalog, err := loadCatalog(*dir)
+	if err != nil {
+		return err
+	}
+
+	urls, err := catalog.ListingURLs(flags.Arg(0), listings.Filter{Phase: *phase, Tag: *tag, PaintseedGroup: *paintseedGroup})
+	if err != nil {
+		return err
+	}
+
+	for _, marketplace := range sortedKeys(urls) {
+		fmt.Printf("%-12s %s\n", marketplace, urls[marketplace])
+	}
+
+	return nil
+}